@@ -29,6 +29,78 @@ resource "rizhiyi_index" "test_index" {
   rotation_period = "10d"
   expired_time    = "25d"
 
+  # set deletion_protection = false and force_destroy = true before removing the index
+  deletion_protection = true
+}
+
+//rizhiyi index with storage tiers
+resource "rizhiyi_index" "tiered_index" {
+  pattern         = "kCompression"
+  name            = "terraform_tiered_index"
+  rotation_period = "1d"
+  expired_time    = "90d"
+
+  storage_lifecycle {
+    hot_days = 7
+    warm {
+      target = "hdd"
+    }
+    cold {
+      after = "30d"
+    }
+    freeze {
+      after = "60d"
+    }
+  }
+
+  field {
+    name      = "message"
+    tokenized = true
+  }
+  field {
+    name = "client_ip"
+    type = "ip"
+  }
+  field {
+    name   = "json.cost"
+    type   = "double"
+    stored = false
+  }
+}
+
+//rizhiyi index catalog
+locals {
+  app_indexes = {
+    app_orders   = { expired_time = "30d", rotation_period = "1d" }
+    app_payments = { expired_time = "180d", rotation_period = "1d" }
+    app_audit    = { expired_time = "365d", rotation_period = "7d" }
+  }
+}
+
+resource "rizhiyi_index_set" "apps" {
+  parallelism = 8
+
+  dynamic "index" {
+    for_each = local.app_indexes
+    content {
+      name            = index.key
+      expired_time    = index.value.expired_time
+      rotation_period = index.value.rotation_period
+    }
+  }
+}
+
+//rizhiyi index usage, for capacity planning
+data "rizhiyi_index_stats" "tiered_index" {
+  index_id = rizhiyi_index.tiered_index.id
+}
+
+//rizhiyi index routing
+resource "rizhiyi_index_match_rule" "nginx" {
+  index_id = rizhiyi_index.test_index.id
+  appname  = "nginx"
+  tag      = "access"
+  priority = 10
 }
 
 //rizhiyi dashboard create
@@ -225,6 +297,72 @@ resource "rizhiyi_parser_rule" "test_parser_rule" {
   category_id = 1000
 }
 
+//rizhiyi parser rule with typed steps
+resource "rizhiyi_parser_rule" "nginx_access" {
+  name    = "nginx_access"
+  logtype = "nginx"
+
+  assignment {
+    appname = "nginx"
+    tag     = "access"
+  }
+
+  step {
+    regex {
+      pattern = "(?<clientip>\\S+) \\S+ \\S+ \\[(?<time>[^\\]]+)\\] \"(?<request>[^\"]*)\" (?<status>\\d+)"
+    }
+  }
+  step {
+    timestamp {
+      field    = "time"
+      format   = "dd/MMM/yyyy:HH:mm:ss Z"
+      timezone = "Asia/Shanghai"
+    }
+  }
+  step {
+    geo {
+      source = "clientip"
+    }
+  }
+  step {
+    add_fields {
+      fields = {
+        env = "prod"
+      }
+    }
+  }
+}
+
+// steps are tested inline, so a mismatch fails the plan before the rule is saved
+data "rizhiyi_parser_rule_test" "nginx_access" {
+  logtype = "nginx"
+  sample_lines = [
+    "10.0.0.1 - - [19/Oct/2026:10:00:00 +0800] \"GET /index.html HTTP/1.1\" 200",
+  ]
+
+  step {
+    regex {
+      pattern = "(?<clientip>\\S+) \\S+ \\S+ \\[(?<time>[^\\]]+)\\] \"(?<request>[^\"]*)\" (?<status>\\d+)"
+    }
+  }
+  step {
+    add_fields {
+      fields = {
+        env = "prod"
+      }
+    }
+  }
+
+  expected_fields {
+    line = 0
+    fields = {
+      clientip = "10.0.0.1"
+      status   = "200"
+      env      = "prod"
+    }
+  }
+}
+
 //rizhiyi account create
 resource "rizhiyi_account" "test_account" {
   name       = "terraform_test_update"
@@ -236,3 +374,213 @@ resource "rizhiyi_account" "test_account" {
     rizhiyi_role.new_role
   ]
 }
+
+//rizhiyi alert maintenance window, applied by running terraform apply when it opens and closes;
+//alerts it covers should list enabled in lifecycle.ignore_changes
+resource "rizhiyi_alert_maintenance" "release_window" {
+  name      = "weekly_release"
+  crontab   = "0 0 2 ? * SUN"
+  duration  = 7200
+  timezone  = "Asia/Shanghai"
+  alert_ids = [rizhiyi_alert.test1_alert.id]
+}
+
+//rizhiyi alert query preview
+data "rizhiyi_alert_preview" "test1_alert" {
+  query           = rizhiyi_alert.test1_alert.query
+  category        = rizhiyi_alert.test1_alert.category
+  check_condition = rizhiyi_alert.test1_alert.check_condition
+}
+
+//rizhiyi alert template and instance
+resource "rizhiyi_alert_template" "error_rate" {
+  name            = "error_rate_over_threshold"
+  query           = "appname:{{appname}} AND ERROR"
+  check_condition = "{\"threshold\":\"info:{{threshold}}\",\"function\":\"count\",\"operator\":\">\",\"timerange\":\"-10m\"}"
+  defaults = {
+    threshold = "100"
+  }
+}
+
+resource "rizhiyi_alert" "nginx_error_rate" {
+  name        = "nginx_error_rate"
+  category    = 0
+  executor_id = 1
+  template_id = rizhiyi_alert_template.error_rate.body
+  template_params = {
+    appname = "nginx"
+  }
+}
+
+//rizhiyi alert trigger history
+data "rizhiyi_alert_history" "test1_alert" {
+  alert_id   = rizhiyi_alert.test1_alert.id
+  time_range = "-1h,now"
+  limit      = 10
+}
+
+//rizhiyi dashboard with typed tab content
+resource "rizhiyi_dashboard" "nginx_overview" {
+  name        = "Nginx概览"
+  app_id      = 1
+  manage_tabs = true
+
+  tabs {
+    name = "总览"
+
+    refresh {
+      time = 5
+      unit = "m"
+      on   = true
+    }
+
+    filter {
+      type    = "text"
+      token   = "host"
+      title   = "主机"
+      default = "*"
+    }
+
+    widget {
+      x          = 0
+      y          = 0
+      w          = 6
+      h          = 5
+      title      = "请求量"
+      query      = "appname:nginx AND hostname:$host$ | timechart span=1m count()"
+      time_range = "-1h,now"
+      chart_type = "line"
+    }
+
+    widget {
+      x          = 6
+      y          = 0
+      w          = 6
+      h          = 5
+      title      = "状态码分布"
+      query      = "appname:nginx AND hostname:$host$ | stats count() by nginx.status"
+      time_range = "-1h,now"
+      chart_type = "pie"
+      chart_options = jsonencode({
+        byFields = ["nginx.status"]
+      })
+
+      drilldown {
+        type         = "dashboard"
+        dashboard_id = rizhiyi_dashboard.shared.id
+
+        token {
+          name  = "status"
+          value = "$click.value$"
+        }
+      }
+    }
+  }
+}
+
+//rizhiyi dashboard tabs owned separately
+resource "rizhiyi_dashboard" "shared" {
+  name        = "共享仪表盘"
+  app_id      = 1
+  manage_tabs = false
+}
+
+resource "rizhiyi_dashboard_tab" "shared_ops" {
+  dashboard_id = rizhiyi_dashboard.shared.id
+  name         = "运维"
+  position     = 0
+
+  widget {
+    title      = "错误数"
+    query      = "ERROR | timechart span=5m count()"
+    time_range = "-1h,now"
+    chart_type = "line"
+  }
+}
+
+//rizhiyi dashboard export and import
+data "rizhiyi_dashboard_export" "ops" {
+  dashboard_id = rizhiyi_dashboard.shared.id
+  output_file  = "${path.module}/ops_dashboard.tar.gz"
+}
+
+resource "rizhiyi_dashboard" "imported" {
+  name              = "导入的仪表盘"
+  app_id            = 1
+  source_file       = data.rizhiyi_dashboard_export.ops.output_file
+  trend_import_mode = "clone"
+}
+
+//rizhiyi trend used by a dashboard widget
+resource "rizhiyi_trend" "error_count" {
+  name       = "错误数趋势"
+  query      = "ERROR | timechart span=5m count()"
+  time_range = "-1h,now"
+  chart_type = "line"
+  app_name   = "default"
+  chart_options = jsonencode({
+    xField = "_time"
+  })
+}
+
+resource "rizhiyi_dashboard_tab" "shared_trend" {
+  dashboard_id = rizhiyi_dashboard.shared.id
+  name         = "趋势"
+
+  widget {
+    type     = "trend"
+    trend_id = rizhiyi_trend.error_count.id
+  }
+}
+
+//rizhiyi dashboard permissions
+resource "rizhiyi_dashboard_permission" "shared" {
+  dashboard_id = rizhiyi_dashboard.shared.id
+
+  grant {
+    role_id = 2
+    access  = "read"
+  }
+
+  grant {
+    account_id = 5
+    access     = "write"
+  }
+}
+
+//rizhiyi dashboard render for review
+data "rizhiyi_dashboard_render" "nginx_overview" {
+  dashboard_id = rizhiyi_dashboard.nginx_overview.id
+  format       = "png"
+  time_range   = "-1h,now"
+  output_file  = "${path.module}/renders/nginx_overview.png"
+}
+
+//rizhiyi weekly report
+resource "rizhiyi_report" "weekly_errors" {
+  name       = "每周错误报表"
+  crontab    = "0 0 8 ? * MON"
+  format     = "pdf"
+  trend_ids  = [rizhiyi_trend.error_count.id]
+  time_range = "-7d,now"
+  recipients = ["ops@example.com", "sre@example.com"]
+  subject    = "[周报] {{name}} {{date}}"
+}
+
+//rizhiyi saved search and an alert built from it
+resource "rizhiyi_saved_search" "nginx_5xx" {
+  name            = "nginx 5xx"
+  query           = "appname:nginx AND nginx.status:>=500"
+  time_range      = "-15m,now"
+  app_name        = "default"
+  resource_groups = ["nginx"]
+  share_role_ids  = [2]
+}
+
+resource "rizhiyi_alert" "nginx_5xx" {
+  name            = "nginx 5xx 告警"
+  saved_search_id       = rizhiyi_saved_search.nginx_5xx.id
+  saved_search_revision = rizhiyi_saved_search.nginx_5xx.revision
+  check_condition       = "count() > 100"
+  executor_name         = "admin"
+}
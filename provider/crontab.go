@@ -0,0 +1,162 @@
+package provider
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronField holds the values one field of a crontab statement matches.
+type cronField struct {
+	min, max int
+	values   map[int]bool
+}
+
+func (f cronField) matches(v int) bool {
+	return f.values[v]
+}
+
+var cronMonthNames = map[string]int{
+	"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+	"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+}
+
+// 与平台一致，星期采用 Quartz 写法：1 为周日，7 为周六
+var cronWeekdayNames = map[string]int{
+	"SUN": 1, "MON": 2, "TUE": 3, "WED": 4, "THU": 5, "FRI": 6, "SAT": 7,
+}
+
+func parseCronValue(s string, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToUpper(s)]; ok {
+		return v, nil
+	}
+	return strconv.Atoi(s)
+}
+
+// parseCronField parses lists, ranges, steps and names of one crontab field.
+// The Quartz specials L, W and # are not supported.
+func parseCronField(s string, min, max int, names map[string]int) (cronField, error) {
+	f := cronField{min: min, max: max, values: map[int]bool{}}
+	if s == "?" {
+		s = "*"
+	}
+	for _, part := range strings.Split(s, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			v, err := strconv.Atoi(part[i+1:])
+			if err != nil || v < 1 {
+				return f, fmt.Errorf("invalid step in %q", part)
+			}
+			step = v
+			part = part[:i]
+		}
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = parseCronValue(bounds[0], names); err != nil {
+				return f, fmt.Errorf("invalid value in %q", part)
+			}
+			if hi, err = parseCronValue(bounds[1], names); err != nil {
+				return f, fmt.Errorf("invalid value in %q", part)
+			}
+		default:
+			v, err := parseCronValue(part, names)
+			if err != nil {
+				return f, fmt.Errorf("invalid value %q", part)
+			}
+			lo, hi = v, v
+			if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return f, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			f.values[v] = true
+		}
+	}
+	return f, nil
+}
+
+// crontabSchedule is a parsed crontab statement in the platform's Quartz format:
+// second minute hour day-of-month month day-of-week [year].
+type crontabSchedule struct {
+	second, minute, hour, dom, month, dow, year cronField
+}
+
+func parseCrontab(s string) (*crontabSchedule, error) {
+	parts := strings.Fields(s)
+	if len(parts) != 6 && len(parts) != 7 {
+		return nil, fmt.Errorf("expected 6 or 7 fields, got %d", len(parts))
+	}
+	if len(parts) == 6 {
+		parts = append(parts, "*")
+	}
+	specs := []struct {
+		min, max int
+		names    map[string]int
+	}{
+		{0, 59, nil}, {0, 59, nil}, {0, 23, nil}, {1, 31, nil},
+		{1, 12, cronMonthNames}, {1, 7, cronWeekdayNames}, {1970, 2099, nil},
+	}
+	fields := make([]cronField, len(specs))
+	for i, spec := range specs {
+		f, err := parseCronField(parts[i], spec.min, spec.max, spec.names)
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = f
+	}
+	return &crontabSchedule{
+		second: fields[0], minute: fields[1], hour: fields[2], dom: fields[3],
+		month: fields[4], dow: fields[5], year: fields[6],
+	}, nil
+}
+
+// matchesMinute reports whether the schedule fires at some second of t's minute.
+func (s *crontabSchedule) matchesMinute(t time.Time) bool {
+	return s.minute.matches(t.Minute()) && s.hour.matches(t.Hour()) &&
+		s.dom.matches(t.Day()) && s.month.matches(int(t.Month())) &&
+		s.dow.matches(int(t.Weekday())+1) && s.year.matches(t.Year())
+}
+
+// lastFiring returns the latest time in (now-within, now] at which the schedule
+// fires, evaluated in now's location.
+func (s *crontabSchedule) lastFiring(now time.Time, within time.Duration) (time.Time, bool) {
+	earliest := now.Add(-within)
+	for m := now.Truncate(time.Minute); m.After(earliest.Add(-time.Minute)); m = m.Add(-time.Minute) {
+		if !s.matchesMinute(m) {
+			continue
+		}
+		for sec := 59; sec >= 0; sec-- {
+			t := m.Add(time.Duration(sec) * time.Second)
+			if !s.second.matches(sec) || t.After(now) {
+				continue
+			}
+			if !t.After(earliest) {
+				break
+			}
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// validateCrontabSchedule checks that a crontab statement can be evaluated by the
+// provider itself, not only that it has the right number of fields.
+func validateCrontabSchedule(i interface{}, k string) (warnings []string, errors []error) {
+	v, ok := i.(string)
+	if !ok {
+		errors = append(errors, fmt.Errorf("expected type of %s to be string", k))
+		return
+	}
+	if _, err := parseCrontab(v); err != nil {
+		errors = append(errors, fmt.Errorf("invalid %s %q: %s", k, v, err))
+	}
+	return
+}
@@ -0,0 +1,323 @@
+package provider
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"terraform-provider-rizhiyi/yottaweb"
+)
+
+// dashboardPackage is a dashboard export: the dashboard, its tabs and the trends
+// its widgets refer to through trendId.
+type dashboardPackage struct {
+	Dashboard map[string]interface{}   `json:"dashboard"`
+	Tabs      []map[string]interface{} `json:"tabs"`
+	Trends    []map[string]interface{} `json:"trends"`
+}
+
+// Server managed fields dropped from exported objects.
+var exportDroppedFields = []string{"id", "uuid", "creator_id", "creator_name", "create_time", "update_time", "last_modified_time", "tabs"}
+
+// readDashboardPackage reads an export file: a JSON document, or a tar (optionally
+// gzipped) holding dashboard.json plus trends.json or trends/*.json.
+func readDashboardPackage(filename string) (*dashboardPackage, error) {
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > 2 && raw[0] == 0x1f && raw[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", filename, err)
+		}
+		if raw, err = io.ReadAll(zr); err != nil {
+			return nil, fmt.Errorf("%s: %s", filename, err)
+		}
+	}
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return parseDashboardPackageJSON(trimmed)
+	}
+	return parseDashboardPackageTar(raw)
+}
+
+func parseDashboardPackageJSON(raw []byte) (*dashboardPackage, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("invalid dashboard export: %s", err)
+	}
+	pkg := &dashboardPackage{}
+	if d, ok := doc["dashboard"].(map[string]interface{}); ok {
+		pkg.Dashboard = d
+	} else {
+		// 没有 dashboard 包装时，整个文档即 dashboard 本身
+		pkg.Dashboard = doc
+	}
+	pkg.Tabs = objectList(doc["tabs"])
+	if len(pkg.Tabs) == 0 {
+		pkg.Tabs = objectList(pkg.Dashboard["tabs"])
+	}
+	pkg.Trends = objectList(doc["trends"])
+	return pkg, nil
+}
+
+func parseDashboardPackageTar(raw []byte) (*dashboardPackage, error) {
+	tr := tar.NewReader(bytes.NewReader(raw))
+	pkg := &dashboardPackage{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid dashboard export: %s", err)
+		}
+		if hdr.Typeflag != tar.TypeReg || !strings.HasSuffix(hdr.Name, ".json") {
+			continue
+		}
+		body, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		name := path.Clean(hdr.Name)
+		switch {
+		case path.Base(name) == "dashboard.json":
+			p, err := parseDashboardPackageJSON(body)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", name, err)
+			}
+			pkg.Dashboard, pkg.Tabs = p.Dashboard, p.Tabs
+			pkg.Trends = append(pkg.Trends, p.Trends...)
+		case path.Base(name) == "trends.json":
+			var list []interface{}
+			if err := json.Unmarshal(body, &list); err != nil {
+				return nil, fmt.Errorf("%s: %s", name, err)
+			}
+			pkg.Trends = append(pkg.Trends, objectList(list)...)
+		case path.Base(path.Dir(name)) == "trends":
+			var trend map[string]interface{}
+			if err := json.Unmarshal(body, &trend); err != nil {
+				return nil, fmt.Errorf("%s: %s", name, err)
+			}
+			pkg.Trends = append(pkg.Trends, trend)
+		}
+	}
+	if pkg.Dashboard == nil {
+		return nil, fmt.Errorf("invalid dashboard export: dashboard.json not found")
+	}
+	return pkg, nil
+}
+
+func objectList(v interface{}) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0)
+	if list, ok := v.([]interface{}); ok {
+		for _, item := range list {
+			if obj, ok := item.(map[string]interface{}); ok {
+				out = append(out, obj)
+			}
+		}
+	}
+	return out
+}
+
+// fileSHA256 returns the hex SHA-256 of a file, used to detect changed exports.
+func fileSHA256(filename string) (string, error) {
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// tabTrendIDs returns the trendId values referenced by the widgets of a tab content.
+func tabTrendIDs(content string) []string {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &raw); err != nil {
+		return nil
+	}
+	ids := make([]string, 0)
+	for _, w := range objectList(raw["widgets"]) {
+		if id := idString(w["trendId"]); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// rewriteTabTrendIDs replaces widget trendId values according to idMap.
+func rewriteTabTrendIDs(content string, idMap map[string]string) (string, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &raw); err != nil {
+		return "", err
+	}
+	for _, w := range objectList(raw["widgets"]) {
+		if newID, ok := idMap[idString(w["trendId"])]; ok {
+			if v, ok := toInt(newID); ok {
+				w["trendId"] = v
+			} else {
+				w["trendId"] = newID
+			}
+		}
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// importPackageTrends creates the trends of a package on the platform and returns
+// the old to new trend ID map plus the IDs created. With reuse, a trend of the
+// same name already on the platform is used instead of a new copy.
+func importPackageTrends(c *yottaweb.Client, pkg *dashboardPackage, reuse bool) (map[string]string, []string, error) {
+	idMap := make(map[string]string)
+	created := make([]string, 0)
+	for _, trend := range pkg.Trends {
+		oldID := idString(trend["id"])
+		name := stringValue(trend["name"])
+		if reuse && name != "" {
+			if rid, _ := c.GetResourceIdByName(name, "v3", "trends"); rid != "" {
+				idMap[oldID] = rid
+				continue
+			}
+		}
+		body := make(map[string]interface{}, len(trend))
+		for k, v := range trend {
+			body[k] = v
+		}
+		for _, k := range exportDroppedFields {
+			delete(body, k)
+		}
+		newID, err := createTrend(c, body)
+		if err != nil {
+			return idMap, created, fmt.Errorf("failed to import trend %q: %s", name, err)
+		}
+		idMap[oldID] = newID
+		created = append(created, newID)
+	}
+	return idMap, created, nil
+}
+
+// createTrend posts a trend and returns its ID.
+func createTrend(c *yottaweb.Client, body map[string]interface{}) (string, error) {
+	endpoint := c.BuildRizhiyiURL(nil, "v3", "trends")
+	resp, err := c.Post(endpoint, body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	id := idFromCreateResponse(bodyBytes)
+	if id == "" {
+		if name, ok := body["name"].(string); ok && name != "" {
+			id, _ = c.GetResourceIdByName(name, "v3", "trends")
+		}
+	}
+	if id == "" {
+		return "", fmt.Errorf("trend created but id not resolvable")
+	}
+	return id, nil
+}
+
+// exportDashboardPackage builds the export package of an existing dashboard.
+func exportDashboardPackage(c *yottaweb.Client, dashboardID string) (*dashboardPackage, error) {
+	dashboard, err := getDashboardObject(c, dashboardID)
+	if err != nil {
+		return nil, err
+	}
+	if dashboard == nil {
+		return nil, fmt.Errorf("dashboard %s not found", dashboardID)
+	}
+
+	pkg := &dashboardPackage{Dashboard: make(map[string]interface{})}
+	for k, v := range dashboard {
+		pkg.Dashboard[k] = v
+	}
+	for _, k := range exportDroppedFields {
+		delete(pkg.Dashboard, k)
+	}
+
+	trendIDs := make(map[string]bool)
+	for _, tab := range orderedDashboardTabs(dashboard) {
+		content := stringValue(tab["content"])
+		pkg.Tabs = append(pkg.Tabs, map[string]interface{}{
+			"name":    tab["name"],
+			"content": content,
+		})
+		for _, id := range tabTrendIDs(content) {
+			trendIDs[id] = true
+		}
+	}
+
+	ids := make([]string, 0, len(trendIDs))
+	for id := range trendIDs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		trend, err := c.GetResourceById(id, "v3", "trends")
+		if err != nil {
+			return nil, fmt.Errorf("failed to export trend %s: %s", id, err)
+		}
+		// 保留原 id，导入时据此重写 trendId
+		pkg.Trends = append(pkg.Trends, trend)
+	}
+	return pkg, nil
+}
+
+// writeDashboardPackage writes a package as JSON, or as a gzipped tar when the
+// file name ends in .tar.gz or .tgz.
+func writeDashboardPackage(pkg *dashboardPackage, filename string) error {
+	doc, err := json.MarshalIndent(pkg, "", "  ")
+	if err != nil {
+		return err
+	}
+	if !strings.HasSuffix(filename, ".tar.gz") && !strings.HasSuffix(filename, ".tgz") {
+		return os.WriteFile(filename, doc, 0644)
+	}
+
+	dashboardDoc, _ := json.MarshalIndent(map[string]interface{}{
+		"dashboard": pkg.Dashboard,
+		"tabs":      pkg.Tabs,
+	}, "", "  ")
+	trendsDoc, _ := json.MarshalIndent(pkg.Trends, "", "  ")
+
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(zw)
+	for _, f := range []struct {
+		name string
+		body []byte
+	}{
+		{"dashboard.json", dashboardDoc},
+		{"trends.json", trendsDoc},
+	} {
+		hdr := &tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.body)), ModTime: time.Now()}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.body); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, buf.Bytes(), 0644)
+}
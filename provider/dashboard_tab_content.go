@@ -0,0 +1,884 @@
+package provider
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// Defaults the dashboard UI writes into every tab; filled in on the way out and
+// dropped again on the way in so they never show up as diffs.
+var (
+	dashboardTabDefaults = map[string]interface{}{
+		"showTitle":  true,
+		"editable":   true,
+		"scheme":     "schemecat1",
+		"theme":      "day",
+		"autoUpdate": true,
+	}
+	dashboardSearchDataDefaults = map[string]interface{}{
+		"now":                 "",
+		"market_day":          float64(0),
+		"highlight":           false,
+		"onlySortByTimestamp": false,
+		"use_spark":           false,
+		"scheme":              "schemecat1",
+		"dataset_ids":         "[]",
+	}
+)
+
+// dashboardTabTypedSchema returns the typed blocks of a tab, used instead of the raw
+// content JSON.
+func dashboardTabTypedSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"refresh": &schema.Schema{
+			Type:        schema.TypeList,
+			Optional:    true,
+			MaxItems:    1,
+			Description: "Auto refresh setting of the tab.",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"time": &schema.Schema{
+						Type:        schema.TypeInt,
+						Optional:    true,
+						Default:     3,
+						Description: "Refresh interval. (default value 3)",
+					},
+					"unit": &schema.Schema{
+						Type:         schema.TypeString,
+						Optional:     true,
+						Default:      "m",
+						ValidateFunc: validation.StringInSlice([]string{"s", "m", "h", "d"}, false),
+						Description:  "Unit of the refresh interval: s, m, h or d. (default value m)",
+					},
+					"on": &schema.Schema{
+						Type:        schema.TypeBool,
+						Optional:    true,
+						Default:     false,
+						Description: "Whether auto refresh is on. (default value false)",
+					},
+					"show_refresh_process": &schema.Schema{
+						Type:        schema.TypeBool,
+						Optional:    true,
+						Default:     true,
+						Description: "Whether the refresh progress is shown. (default value true)",
+					},
+				},
+			},
+		},
+		"filter": &schema.Schema{
+			Type:        schema.TypeList,
+			Optional:    true,
+			Description: "Input filters of the tab, in display order.",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"type": &schema.Schema{
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: "Filter type, for example: text, dropdown, dynamicDropdown, timerange.",
+					},
+					"token": &schema.Schema{
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: "Token name; widget queries refer to it as $token$.",
+					},
+					"title": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Title of the filter.",
+					},
+					"description": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Description of the filter.",
+					},
+					"default": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Default value: the text of a text filter, the time range of a timerange filter or the selected value of a dropdown.",
+					},
+					"options": &schema.Schema{
+						Type:        schema.TypeList,
+						Optional:    true,
+						Description: "Static options of a dropdown filter.",
+						Elem: &schema.Resource{
+							Schema: map[string]*schema.Schema{
+								"label": &schema.Schema{
+									Type:        schema.TypeString,
+									Required:    true,
+									Description: "Displayed label of the option.",
+								},
+								"value": &schema.Schema{
+									Type:        schema.TypeString,
+									Required:    true,
+									Description: "Value of the option.",
+								},
+							},
+						},
+					},
+					"prefix": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Text put before the token value.",
+					},
+					"suffix": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Text put after the token value.",
+					},
+					"delimiter": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Delimiter between the values of a multiple selection.",
+					},
+					"selection_mode": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Selection mode of a dropdown: single or multiple.",
+					},
+					"dynamic_query": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Query that fills a dynamicDropdown.",
+					},
+					"dynamic_field": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Result field used as the values of a dynamicDropdown.",
+					},
+					"dynamic_time_range": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Time range of the dynamic_query.",
+					},
+					"width": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Default:     "200px",
+						Description: "Display width of the filter. (default value 200px)",
+					},
+					"visible": &schema.Schema{
+						Type:        schema.TypeBool,
+						Optional:    true,
+						Default:     true,
+						Description: "Whether the filter is shown. (default value true)",
+					},
+					"search_on_change": &schema.Schema{
+						Type:        schema.TypeBool,
+						Optional:    true,
+						Default:     false,
+						Description: "Whether widgets search again when the value changes. (default value false)",
+					},
+					"set_as_global": &schema.Schema{
+						Type:        schema.TypeBool,
+						Optional:    true,
+						Default:     false,
+						Description: "Whether a timerange filter applies to every widget. (default value false)",
+					},
+				},
+			},
+		},
+		"widget": &schema.Schema{
+			Type:        schema.TypeList,
+			Optional:    true,
+			Description: "Widgets of the tab.",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"id": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Widget ID. When not set it is derived from the tab name and the widget title (or query when untitled), so reordering widgets keeps their IDs.",
+					},
+					"x": &schema.Schema{
+						Type:        schema.TypeInt,
+						Optional:    true,
+						Default:     0,
+						Description: "Column of the widget in the grid.",
+					},
+					"y": &schema.Schema{
+						Type:        schema.TypeInt,
+						Optional:    true,
+						Default:     0,
+						Description: "Row of the widget in the grid.",
+					},
+					"w": &schema.Schema{
+						Type:        schema.TypeInt,
+						Optional:    true,
+						Default:     12,
+						Description: "Width of the widget in grid columns. (default value 12)",
+					},
+					"h": &schema.Schema{
+						Type:        schema.TypeInt,
+						Optional:    true,
+						Default:     5,
+						Description: "Height of the widget in grid rows. (default value 5)",
+					},
+					"type": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Default:     "trend",
+						Description: "Widget type. (default value trend)",
+					},
+					"trend_id": &schema.Schema{
+						Type:        schema.TypeInt,
+						Optional:    true,
+						Description: "ID of the saved trend shown by the widget, for example rizhiyi_trend.example.id.",
+					},
+					"import_type": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Default:     "clone",
+						Description: "How the trend is imported into the widget. (default value clone)",
+					},
+					"title": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Title of the widget.",
+					},
+					"query": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Search content of the widget.",
+					},
+					"time_range": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Time range of the widget search, for example: -1h,now.",
+					},
+					"chart_type": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Chart type, for example: line, column, pie, sequence.",
+					},
+					"chart_options": &schema.Schema{
+						Type:             schema.TypeString,
+						Optional:         true,
+						Default:          "{}",
+						ValidateFunc:     validation.StringIsJSON,
+						DiffSuppressFunc: suppressEquivalentJSON,
+						Description:      "Other chart settings merged into the widget search data (JSON string), for example xField or byFields.",
+					},
+					"drilldown": &schema.Schema{
+						Type:        schema.TypeList,
+						Optional:    true,
+						MaxItems:    1,
+						Description: "Where clicking the widget leads.",
+						Elem: &schema.Resource{
+							Schema: map[string]*schema.Schema{
+								"type": &schema.Schema{
+									Type:         schema.TypeString,
+									Required:     true,
+									ValidateFunc: validation.StringInSlice([]string{"dashboard", "url", "search"}, false),
+									Description:  "Drilldown target type: dashboard, url or search.",
+								},
+								"dashboard_id": &schema.Schema{
+									Type:        schema.TypeInt,
+									Optional:    true,
+									Description: "Target dashboard of a dashboard drilldown, for example rizhiyi_dashboard.x.id.",
+								},
+								"tab_id": &schema.Schema{
+									Type:        schema.TypeInt,
+									Optional:    true,
+									Description: "Target tab of a dashboard drilldown.",
+								},
+								"url": &schema.Schema{
+									Type:        schema.TypeString,
+									Optional:    true,
+									Description: "Target of a url drilldown.",
+								},
+								"query": &schema.Schema{
+									Type:        schema.TypeString,
+									Optional:    true,
+									Description: "Search content of a search drilldown.",
+								},
+								"time_range": &schema.Schema{
+									Type:        schema.TypeString,
+									Optional:    true,
+									Description: "Time range of a search drilldown.",
+								},
+								"new_window": &schema.Schema{
+									Type:        schema.TypeBool,
+									Optional:    true,
+									Default:     false,
+									Description: "Whether the target opens in a new window. (default value false)",
+								},
+								"token": &schema.Schema{
+									Type:        schema.TypeList,
+									Optional:    true,
+									Description: "Tokens passed to the target dashboard of a dashboard drilldown, filling its filters.",
+									Elem: &schema.Resource{
+										Schema: map[string]*schema.Schema{
+											"name": &schema.Schema{
+												Type:        schema.TypeString,
+												Required:    true,
+												Description: "Token name of a filter of the target dashboard.",
+											},
+											"value": &schema.Schema{
+												Type:        schema.TypeString,
+												Required:    true,
+												Description: "Token value, for example $click.value$.",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// tabHasTypedContent reports whether a tab uses the typed blocks instead of content.
+func tabHasTypedContent(tab map[string]interface{}) bool {
+	for _, k := range []string{"refresh", "filter", "widget"} {
+		if v, ok := tab[k].([]interface{}); ok && len(v) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// dashboardTabContent returns the wire content of a tab, serializing the typed
+// blocks when they are used.
+func dashboardTabContent(tab map[string]interface{}) (string, error) {
+	name, _ := tab["name"].(string)
+	if !tabHasTypedContent(tab) {
+		content, _ := tab["content"].(string)
+		if content == "" {
+			return "", fmt.Errorf("tab %q needs either content or refresh/filter/widget blocks", name)
+		}
+		return content, nil
+	}
+	content, err := expandDashboardTabContent(name, tab)
+	if err != nil {
+		return "", fmt.Errorf("tab %q: %s", name, err)
+	}
+	b, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func expandDashboardTabContent(tabName string, tab map[string]interface{}) (map[string]interface{}, error) {
+	content := make(map[string]interface{})
+	for k, v := range dashboardTabDefaults {
+		content[k] = v
+	}
+
+	refresh := map[string]interface{}{
+		"time":               3,
+		"unit":               "m",
+		"on":                 false,
+		"showRefreshProcess": true,
+	}
+	if v, ok := tab["refresh"].([]interface{}); ok && len(v) > 0 && v[0] != nil {
+		r := v[0].(map[string]interface{})
+		refresh["time"] = r["time"]
+		refresh["unit"] = r["unit"]
+		refresh["on"] = r["on"]
+		refresh["showRefreshProcess"] = r["show_refresh_process"]
+	}
+	content["refresh"] = refresh
+
+	filters := make([]interface{}, 0)
+	if v, ok := tab["filter"].([]interface{}); ok {
+		for _, f := range v {
+			filters = append(filters, expandDashboardFilter(f.(map[string]interface{})))
+		}
+	}
+	content["filters"] = filters
+	content["showFilters"] = len(filters) > 0
+
+	widgets := make([]interface{}, 0)
+	activeDrilldown := false
+	if v, ok := tab["widget"].([]interface{}); ok {
+		ids := dashboardWidgetIDs(tabName, v)
+		for i, w := range v {
+			widget, err := expandDashboardWidget(ids[i], i, w.(map[string]interface{}))
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := widget["drilldown"]; ok {
+				activeDrilldown = true
+			}
+			widgets = append(widgets, widget)
+		}
+	}
+	content["widgets"] = widgets
+	content["activeDrilldown"] = activeDrilldown
+	return content, nil
+}
+
+func expandDashboardFilter(f map[string]interface{}) map[string]interface{} {
+	filterType := f["type"].(string)
+	filter := map[string]interface{}{
+		"filterType":     "token",
+		"type":           filterType,
+		"token":          f["token"],
+		"title":          f["title"],
+		"description":    f["description"],
+		"width":          f["width"],
+		"visible":        f["visible"],
+		"searchOnChange": f["search_on_change"],
+		"prefix":         f["prefix"],
+		"suffix":         f["suffix"],
+	}
+	defaultKey := dashboardFilterDefaultKey(filterType)
+	filter[defaultKey] = f["default"]
+	if filterType == "timerange" {
+		filter["setAsGlobal"] = f["set_as_global"]
+	}
+
+	optional := map[string]string{
+		"delimiter":          "delimiter",
+		"selection_mode":     "selectionMode",
+		"dynamic_query":      "dynamicQuery",
+		"dynamic_field":      "dynamicFieldValue",
+		"dynamic_time_range": "dynamicSearchTimeRange",
+	}
+	for tfKey, wireKey := range optional {
+		if v, ok := f[tfKey].(string); ok && v != "" {
+			filter[wireKey] = v
+		}
+	}
+	if opts, ok := f["options"].([]interface{}); ok && len(opts) > 0 {
+		values := make([]interface{}, 0, len(opts))
+		for _, o := range opts {
+			om := o.(map[string]interface{})
+			values = append(values, map[string]interface{}{
+				"label": om["label"],
+				"value": om["value"],
+			})
+		}
+		filter["dropdownValues"] = values
+	}
+	return filter
+}
+
+func dashboardFilterDefaultKey(filterType string) string {
+	switch filterType {
+	case "timerange":
+		return "timeValue"
+	case "dropdown", "dynamicDropdown":
+		return "dropdownSelectedValue"
+	default:
+		return "textValue"
+	}
+}
+
+// dashboardWidgetIDs returns the ID of each widget of a tab: the id set on the
+// widget, or one derived from the tab name and the widget title, falling back to
+// its query and trend_id. Widgets with the same title are told apart by their
+// order among each other.
+func dashboardWidgetIDs(tabName string, widgets []interface{}) []string {
+	ids := make([]string, len(widgets))
+	seen := make(map[string]int)
+	for i, w := range widgets {
+		wm, _ := w.(map[string]interface{})
+		key := stringValue(wm["title"])
+		if key == "" {
+			key = stringValue(wm["query"])
+		}
+		if key == "" {
+			if v, ok := wm["trend_id"].(int); ok && v > 0 {
+				key = "trend:" + strconv.Itoa(v)
+			}
+		}
+		seen[key]++
+		if n := seen[key]; n > 1 {
+			key += "#" + strconv.Itoa(n)
+		}
+		if id := stringValue(wm["id"]); id != "" {
+			ids[i] = id
+			continue
+		}
+		sum := sha1.Sum([]byte(tabName + "/" + key))
+		ids[i] = hex.EncodeToString(sum[:])[:32]
+	}
+	return ids
+}
+
+func expandDashboardWidget(id string, index int, w map[string]interface{}) (map[string]interface{}, error) {
+	searchData := make(map[string]interface{})
+	for k, v := range dashboardSearchDataDefaults {
+		searchData[k] = v
+	}
+	if opts, ok := w["chart_options"].(string); ok && opts != "" {
+		var extra map[string]interface{}
+		if err := json.Unmarshal([]byte(opts), &extra); err != nil {
+			return nil, fmt.Errorf("widget %d chart_options: %s", index, err)
+		}
+		for k, v := range extra {
+			searchData[k] = v
+		}
+	}
+	searchData["query"] = w["query"]
+	searchData["time_range"] = w["time_range"]
+	searchData["chartType"] = w["chart_type"]
+	if v, ok := w["title"].(string); ok && v != "" {
+		searchData["trendName"] = v
+	}
+
+	widget := map[string]interface{}{
+		"id":         id,
+		"x":          w["x"],
+		"y":          w["y"],
+		"w":          w["w"],
+		"h":          w["h"],
+		"type":       w["type"],
+		"importType": w["import_type"],
+		"searchData": searchData,
+	}
+	if v, ok := w["trend_id"].(int); ok && v > 0 {
+		widget["trendId"] = v
+	}
+	if v, ok := w["drilldown"].([]interface{}); ok && len(v) > 0 && v[0] != nil {
+		drilldown, err := expandDashboardDrilldown(v[0].(map[string]interface{}))
+		if err != nil {
+			return nil, fmt.Errorf("widget %d drilldown: %s", index, err)
+		}
+		widget["drilldown"] = drilldown
+	}
+	return widget, nil
+}
+
+func expandDashboardDrilldown(dd map[string]interface{}) (map[string]interface{}, error) {
+	drilldown := map[string]interface{}{
+		"type":  dd["type"],
+		"blank": dd["new_window"],
+	}
+	switch dd["type"] {
+	case "dashboard":
+		if v, _ := dd["dashboard_id"].(int); v <= 0 {
+			return nil, fmt.Errorf("type dashboard needs dashboard_id")
+		}
+		drilldown["dashboardId"] = dd["dashboard_id"]
+		if v, ok := dd["tab_id"].(int); ok && v > 0 {
+			drilldown["tabId"] = v
+		}
+		tokens := make([]interface{}, 0)
+		if v, ok := dd["token"].([]interface{}); ok {
+			for _, t := range v {
+				tm := t.(map[string]interface{})
+				tokens = append(tokens, map[string]interface{}{
+					"name":  tm["name"],
+					"value": tm["value"],
+				})
+			}
+		}
+		drilldown["tokens"] = tokens
+	case "url":
+		if stringValue(dd["url"]) == "" {
+			return nil, fmt.Errorf("type url needs url")
+		}
+		drilldown["url"] = dd["url"]
+	case "search":
+		if stringValue(dd["query"]) == "" {
+			return nil, fmt.Errorf("type search needs query")
+		}
+		drilldown["query"] = dd["query"]
+		drilldown["time_range"] = dd["time_range"]
+	}
+	return drilldown, nil
+}
+
+// flattenDashboardTabContent maps the wire content of a tab back onto the typed
+// blocks. Widget IDs the provider derived are left empty, as they were not set.
+func flattenDashboardTabContent(tabName, content string) (map[string]interface{}, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &raw); err != nil {
+		return nil, err
+	}
+	typed := map[string]interface{}{
+		"refresh": []interface{}{},
+		"filter":  []interface{}{},
+		"widget":  []interface{}{},
+	}
+
+	if r, ok := raw["refresh"].(map[string]interface{}); ok {
+		refresh := map[string]interface{}{
+			"time":                 3,
+			"unit":                 "m",
+			"on":                   false,
+			"show_refresh_process": true,
+		}
+		if v, ok := toInt(r["time"]); ok {
+			refresh["time"] = v
+		}
+		if v, ok := r["unit"].(string); ok {
+			refresh["unit"] = v
+		}
+		if v, ok := r["on"].(bool); ok {
+			refresh["on"] = v
+		}
+		if v, ok := r["showRefreshProcess"].(bool); ok {
+			refresh["show_refresh_process"] = v
+		}
+		typed["refresh"] = []interface{}{refresh}
+	}
+
+	if filters, ok := raw["filters"].([]interface{}); ok {
+		out := make([]interface{}, 0, len(filters))
+		for _, f := range filters {
+			if fm, ok := f.(map[string]interface{}); ok {
+				out = append(out, flattenDashboardFilter(fm))
+			}
+		}
+		typed["filter"] = out
+	}
+
+	if widgets, ok := raw["widgets"].([]interface{}); ok {
+		out := make([]interface{}, 0, len(widgets))
+		for _, w := range widgets {
+			if wm, ok := w.(map[string]interface{}); ok {
+				out = append(out, flattenDashboardWidget(wm))
+			}
+		}
+		unset := make([]interface{}, 0, len(out))
+		for _, w := range out {
+			wm := w.(map[string]interface{})
+			unset = append(unset, map[string]interface{}{
+				"title":    wm["title"],
+				"query":    wm["query"],
+				"trend_id": wm["trend_id"],
+			})
+		}
+		for i, id := range dashboardWidgetIDs(tabName, unset) {
+			if w := out[i].(map[string]interface{}); w["id"] == id {
+				w["id"] = ""
+			}
+		}
+		typed["widget"] = out
+	}
+	return typed, nil
+}
+
+func flattenDashboardFilter(f map[string]interface{}) map[string]interface{} {
+	filterType, _ := f["type"].(string)
+	filter := map[string]interface{}{
+		"type":               filterType,
+		"token":              stringValue(f["token"]),
+		"title":              stringValue(f["title"]),
+		"description":        stringValue(f["description"]),
+		"default":            stringValue(f[dashboardFilterDefaultKey(filterType)]),
+		"prefix":             stringValue(f["prefix"]),
+		"suffix":             stringValue(f["suffix"]),
+		"delimiter":          stringValue(f["delimiter"]),
+		"selection_mode":     stringValue(f["selectionMode"]),
+		"dynamic_query":      stringValue(f["dynamicQuery"]),
+		"dynamic_field":      stringValue(f["dynamicFieldValue"]),
+		"dynamic_time_range": stringValue(f["dynamicSearchTimeRange"]),
+		"width":              "200px",
+		"visible":            true,
+		"search_on_change":   false,
+		"set_as_global":      false,
+	}
+	if v, ok := f["width"].(string); ok && v != "" {
+		filter["width"] = v
+	}
+	if v, ok := f["visible"].(bool); ok {
+		filter["visible"] = v
+	}
+	if v, ok := f["searchOnChange"].(bool); ok {
+		filter["search_on_change"] = v
+	}
+	if v, ok := f["setAsGlobal"].(bool); ok {
+		filter["set_as_global"] = v
+	}
+	options := make([]interface{}, 0)
+	if values, ok := f["dropdownValues"].([]interface{}); ok {
+		for _, o := range values {
+			if om, ok := o.(map[string]interface{}); ok {
+				options = append(options, map[string]interface{}{
+					"label": stringValue(om["label"]),
+					"value": stringValue(om["value"]),
+				})
+			}
+		}
+	}
+	filter["options"] = options
+	return filter
+}
+
+func flattenDashboardWidget(w map[string]interface{}) map[string]interface{} {
+	widget := map[string]interface{}{
+		"id":            stringValue(w["id"]),
+		"x":             0,
+		"y":             0,
+		"w":             12,
+		"h":             5,
+		"type":          "trend",
+		"trend_id":      0,
+		"import_type":   "clone",
+		"title":         "",
+		"query":         "",
+		"time_range":    "",
+		"chart_type":    "",
+		"chart_options": "{}",
+		"drilldown":     []interface{}{},
+	}
+	for _, k := range []string{"x", "y", "w", "h"} {
+		if v, ok := toInt(w[k]); ok {
+			widget[k] = v
+		}
+	}
+	if v, ok := w["type"].(string); ok && v != "" {
+		widget["type"] = v
+	}
+	if v, ok := toInt(w["trendId"]); ok {
+		widget["trend_id"] = v
+	}
+	if v, ok := w["importType"].(string); ok && v != "" {
+		widget["import_type"] = v
+	}
+
+	if sd, ok := w["searchData"].(map[string]interface{}); ok {
+		extra := make(map[string]interface{})
+		for k, v := range sd {
+			switch k {
+			case "query":
+				widget["query"] = stringValue(v)
+			case "time_range":
+				widget["time_range"] = stringValue(v)
+			case "chartType":
+				widget["chart_type"] = stringValue(v)
+			case "trendName":
+				widget["title"] = stringValue(v)
+			default:
+				if def, ok := dashboardSearchDataDefaults[k]; ok && reflect.DeepEqual(def, v) {
+					continue
+				}
+				extra[k] = v
+			}
+		}
+		if len(extra) > 0 {
+			b, _ := json.Marshal(extra)
+			widget["chart_options"] = string(b)
+		}
+	}
+
+	if dd, ok := w["drilldown"].(map[string]interface{}); ok {
+		drilldown := map[string]interface{}{
+			"type":         stringValue(dd["type"]),
+			"dashboard_id": 0,
+			"tab_id":       0,
+			"url":          stringValue(dd["url"]),
+			"query":        stringValue(dd["query"]),
+			"time_range":   stringValue(dd["time_range"]),
+			"new_window":   false,
+		}
+		if v, ok := toInt(dd["dashboardId"]); ok {
+			drilldown["dashboard_id"] = v
+		}
+		if v, ok := toInt(dd["tabId"]); ok {
+			drilldown["tab_id"] = v
+		}
+		if v, ok := dd["blank"].(bool); ok {
+			drilldown["new_window"] = v
+		}
+		tokens := make([]interface{}, 0)
+		for _, t := range objectList(dd["tokens"]) {
+			tokens = append(tokens, map[string]interface{}{
+				"name":  stringValue(t["name"]),
+				"value": stringValue(t["value"]),
+			})
+		}
+		drilldown["token"] = tokens
+		widget["drilldown"] = []interface{}{drilldown}
+	}
+	return widget
+}
+
+// stringValue returns v as a string, or "" when it is missing or not a string.
+func stringValue(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+// dashboardTokenPattern matches the $token$ references of queries, urls and titles.
+var dashboardTokenPattern = regexp.MustCompile(`\$([A-Za-z_][A-Za-z0-9_.]*)\$`)
+
+// dashboardClickTokenPrefix marks the tokens a drilldown gets from the clicked
+// chart element, such as $click.value$.
+const dashboardClickTokenPrefix = "click."
+
+// checkDashboardTabReferences checks at plan time that every $token$ used by the
+// widgets and filters of a typed tab is defined by one of its filters. Drilldowns
+// may also use the click tokens. Values unknown until apply read as empty and are
+// skipped.
+func checkDashboardTabReferences(tab map[string]interface{}) error {
+	name, _ := tab["name"].(string)
+	defined := make(map[string]bool)
+	filters, _ := tab["filter"].([]interface{})
+	for _, f := range filters {
+		if fm, ok := f.(map[string]interface{}); ok {
+			defined[stringValue(fm["token"])] = true
+		}
+	}
+
+	check := func(where, text string, click bool) error {
+		for _, m := range dashboardTokenPattern.FindAllStringSubmatch(text, -1) {
+			token := m[1]
+			if defined[token] || (click && strings.HasPrefix(token, dashboardClickTokenPrefix)) {
+				continue
+			}
+			return fmt.Errorf("tab %q: %s uses $%s$, which no filter of the tab defines", name, where, token)
+		}
+		return nil
+	}
+
+	for _, f := range filters {
+		fm, ok := f.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if err := check(fmt.Sprintf("filter %q dynamic_query", stringValue(fm["token"])), stringValue(fm["dynamic_query"]), false); err != nil {
+			return err
+		}
+	}
+
+	widgets, _ := tab["widget"].([]interface{})
+	for i, w := range widgets {
+		wm, ok := w.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, k := range []string{"title", "query", "time_range"} {
+			if err := check(fmt.Sprintf("widget %d %s", i, k), stringValue(wm[k]), false); err != nil {
+				return err
+			}
+		}
+		dds, _ := wm["drilldown"].([]interface{})
+		if len(dds) == 0 || dds[0] == nil {
+			continue
+		}
+		dd := dds[0].(map[string]interface{})
+		for _, k := range []string{"url", "query", "time_range"} {
+			if err := check(fmt.Sprintf("widget %d drilldown %s", i, k), stringValue(dd[k]), true); err != nil {
+				return err
+			}
+		}
+		tokens, _ := dd["token"].([]interface{})
+		for _, t := range tokens {
+			tm, ok := t.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if err := check(fmt.Sprintf("widget %d drilldown token %q", i, stringValue(tm["name"])), stringValue(tm["value"]), true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"terraform-provider-rizhiyi/yottaweb"
+)
+
+const (
+	alertStateOK         = "ok"
+	alertStateFiring     = "firing"
+	alertStateSuppressed = "suppressed"
+)
+
+func dataSourceAlertHistory() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceAlertHistoryRead,
+
+		Schema: map[string]*schema.Schema{
+			"alert_id": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"alert_id", "alert_name"},
+				Description:  "ID of the alert, for example rizhiyi_alert.x.id.",
+			},
+			"alert_name": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Name of the alert, resolved to alert_id.",
+			},
+			"time_range": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "-24h,now",
+				Description: "Time range of the returned triggers, for example: -24h,now. (default value -24h,now)",
+			},
+			"limit": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      20,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Maximum number of triggers returned, newest first. (default value 20)",
+			},
+			"state": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Current trigger state of the alert: ok, firing, suppressed or recovering.",
+			},
+			"last_trigger_time": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Time of the latest trigger (RFC 3339), empty if none.",
+			},
+			"triggers": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Recent triggers of the alert, newest first.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": &schema.Schema{
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "ID of the alert record.",
+						},
+						"time": &schema.Schema{
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Trigger time (RFC 3339).",
+						},
+						"level": &schema.Schema{
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Trigger level, for example: low, mid, high.",
+						},
+						"result_rows": &schema.Schema{
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Number of result rows or events that triggered the alert.",
+						},
+						"recovered": &schema.Schema{
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether the trigger has recovered.",
+						},
+						"suppressed": &schema.Schema{
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether the notification of the trigger was suppressed.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceAlertHistoryRead(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	id := d.Get("alert_id").(string)
+	name := d.Get("alert_name").(string)
+	if id == "" {
+		rid, err := getAlertIdByName(c, name)
+		if err != nil {
+			return err
+		}
+		if rid == "" {
+			return fmt.Errorf("alert %q not found", name)
+		}
+		id = rid
+	}
+
+	alert, err := c.GetResourceById(id, "v3", "alerts")
+	if err != nil {
+		return fmt.Errorf("failed to read alert %s: %s", id, err)
+	}
+	records, err := getAlertRecords(c, id, d.Get("time_range").(string), d.Get("limit").(int))
+	if err != nil {
+		return err
+	}
+
+	triggers := make([]map[string]interface{}, 0, len(records))
+	for _, r := range records {
+		triggers = append(triggers, flattenAlertRecord(r))
+	}
+
+	state := alertStateOK
+	if v, ok := alert["state"].(string); ok && v != "" {
+		state = v
+	} else if len(triggers) > 0 {
+		state = alertStateFromLatest(triggers[0])
+	}
+
+	d.SetId(id)
+	d.Set("alert_id", id)
+	d.Set("state", state)
+	if len(triggers) > 0 {
+		d.Set("last_trigger_time", triggers[0]["time"])
+	} else {
+		d.Set("last_trigger_time", "")
+	}
+	if err := d.Set("triggers", triggers); err != nil {
+		return err
+	}
+	return nil
+}
+
+// getAlertRecords lists the trigger records of an alert, newest first.
+func getAlertRecords(c *yottaweb.Client, id, timeRange string, limit int) ([]map[string]interface{}, error) {
+	params := url.Values{}
+	params.Set("time_range", timeRange)
+	params.Set("size", strconv.Itoa(limit))
+	params.Set("order", "desc")
+	endpoint := c.BuildRizhiyiURL(params, "v3", "alerts", id, "records")
+	resp, err := c.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read records of alert %s: %s", id, err)
+	}
+	defer resp.Body.Close()
+
+	var data map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+	records := make([]map[string]interface{}, 0)
+	for _, item := range listFromResponse(data) {
+		if obj, ok := item.(map[string]interface{}); ok {
+			records = append(records, obj)
+		}
+		if len(records) >= limit {
+			break
+		}
+	}
+	return records, nil
+}
+
+func flattenAlertRecord(r map[string]interface{}) map[string]interface{} {
+	trigger := map[string]interface{}{
+		"id":          idString(r["id"]),
+		"time":        "",
+		"level":       "",
+		"result_rows": 0,
+		"recovered":   false,
+		"suppressed":  false,
+	}
+	for _, k := range []string{"send_time", "timestamp", "create_time"} {
+		if ms, ok := toInt(r[k]); ok && ms > 0 {
+			trigger["time"] = time.Unix(0, int64(ms)*int64(time.Millisecond)).UTC().Format(time.RFC3339)
+			break
+		}
+		if s, ok := r[k].(string); ok && s != "" {
+			trigger["time"] = s
+			break
+		}
+	}
+	for _, k := range []string{"level", "alert_level"} {
+		if v, ok := r[k]; ok && v != nil {
+			trigger["level"] = fmt.Sprintf("%v", v)
+			break
+		}
+	}
+	for _, k := range []string{"result_count", "result_rows", "hits"} {
+		if v, ok := toInt(r[k]); ok {
+			trigger["result_rows"] = v
+			break
+		}
+	}
+	for _, k := range []string{"is_recover", "recovered"} {
+		if v, ok := r[k].(bool); ok {
+			trigger["recovered"] = v
+			break
+		}
+	}
+	for _, k := range []string{"is_restrained", "suppressed"} {
+		if v, ok := r[k].(bool); ok {
+			trigger["suppressed"] = v
+			break
+		}
+	}
+	return trigger
+}
+
+// alertStateFromLatest derives the current state from the newest trigger when the
+// platform does not report one; "recovering" is only ever reported by the platform.
+func alertStateFromLatest(latest map[string]interface{}) string {
+	switch {
+	case latest["recovered"].(bool):
+		return alertStateOK
+	case latest["suppressed"].(bool):
+		return alertStateSuppressed
+	default:
+		return alertStateFiring
+	}
+}
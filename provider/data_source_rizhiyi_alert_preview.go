@@ -0,0 +1,244 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"terraform-provider-rizhiyi/yottaweb"
+)
+
+func dataSourceAlertPreview() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceAlertPreviewRead,
+
+		Schema: map[string]*schema.Schema{
+			"query": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Search content to run, normally the query of a rizhiyi_alert.",
+			},
+			"category": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntBetween(0, 4),
+				Description:  "Monitoring type of the alert, see rizhiyi_alert.category. 0 runs an event search, other types run a statistics search. (default value 0)",
+			},
+			"check_condition": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsJSON,
+				Description:  "Monitoring trigger conditions of the alert, see rizhiyi_alert.check_condition. Used to compute would_fire.",
+			},
+			"time_range": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Time range searched, for example: -15m,now. Defaults to the timerange of check_condition, or -15m,now.",
+			},
+			"timeout": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      60,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Seconds to wait for the search job to finish. (default value 60)",
+			},
+			"max_rows": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      10,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Maximum number of result rows returned in rows. (default value 10)",
+			},
+			"total": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of events or rows the query returned.",
+			},
+			"fields": &schema.Schema{
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Computed:    true,
+				Description: "Field names of a statistics result.",
+			},
+			"rows": &schema.Schema{
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Computed:    true,
+				Description: "First result rows, each encoded as a JSON object string.",
+			},
+			"value": &schema.Schema{
+				Type:        schema.TypeFloat,
+				Computed:    true,
+				Description: "Value compared against the thresholds of check_condition.",
+			},
+			"would_fire": &schema.Schema{
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether check_condition would have triggered on this result.",
+			},
+			"fired_level": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Highest threshold level of check_condition that would have triggered, empty if none.",
+			},
+		},
+	}
+}
+
+func dataSourceAlertPreviewRead(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	query := d.Get("query").(string)
+	category := d.Get("category").(int)
+	timeout := time.Duration(d.Get("timeout").(int)) * time.Second
+	maxRows := d.Get("max_rows").(int)
+
+	var condition map[string]interface{}
+	if v, ok := d.GetOk("check_condition"); ok {
+		if err := json.Unmarshal([]byte(v.(string)), &condition); err != nil {
+			return fmt.Errorf("invalid check_condition: %s", err)
+		}
+	}
+
+	timeRange := d.Get("time_range").(string)
+	if timeRange == "" {
+		timeRange = "-15m,now"
+		if tr, ok := condition["timerange"].(string); ok && tr != "" {
+			timeRange = tr + ",now"
+		}
+	}
+
+	searchCategory := yottaweb.SearchCategoryEvents
+	if category != 0 {
+		searchCategory = yottaweb.SearchCategorySheets
+	}
+	result, err := c.RunSearch(query, timeRange, searchCategory, timeout)
+	if err != nil {
+		return fmt.Errorf("alert query preview failed: %s", err)
+	}
+
+	rows := make([]string, 0)
+	for i, row := range result.Rows {
+		if i >= maxRows {
+			break
+		}
+		b, _ := json.Marshal(row)
+		rows = append(rows, string(b))
+	}
+
+	value := alertConditionValue(condition, result)
+	level, fired := evaluateAlertCondition(condition, value)
+
+	h := sha256.New()
+	for _, v := range []string{query, strconv.Itoa(category), timeRange, d.Get("check_condition").(string), strconv.Itoa(maxRows)} {
+		h.Write([]byte(v + "\n"))
+	}
+	d.SetId(hex.EncodeToString(h.Sum(nil)))
+	d.Set("time_range", timeRange)
+	d.Set("total", result.Total)
+	d.Set("fields", result.Fields)
+	d.Set("rows", rows)
+	d.Set("value", value)
+	d.Set("would_fire", fired)
+	d.Set("fired_level", level)
+	return nil
+}
+
+// alertConditionValue picks the value a check_condition is compared against: the
+// value of the condition's field most likely to trigger when one is set (the
+// smallest for < and <=, otherwise the largest), or else the hit count.
+func alertConditionValue(condition map[string]interface{}, result *yottaweb.SearchResult) float64 {
+	field, _ := condition["field"].(string)
+	if field == "" {
+		return float64(result.Total)
+	}
+	operator, _ := condition["operator"].(string)
+	lowest := operator == "<" || operator == "<="
+	value, found := 0.0, false
+	for _, row := range result.Rows {
+		v, ok := toFloat(row[field])
+		if !ok {
+			continue
+		}
+		if !found || (lowest && v < value) || (!lowest && v > value) {
+			value, found = v, true
+		}
+	}
+	return value
+}
+
+// evaluateAlertCondition compares value against the "level:value" thresholds of a
+// check_condition and returns the highest level that triggers.
+func evaluateAlertCondition(condition map[string]interface{}, value float64) (string, bool) {
+	threshold, _ := condition["threshold"].(string)
+	operator, _ := condition["operator"].(string)
+	if threshold == "" {
+		return "", false
+	}
+
+	type levelThreshold struct {
+		level string
+		value float64
+	}
+	thresholds := make([]levelThreshold, 0)
+	for _, part := range strings.Split(threshold, ",") {
+		level, raw := "", strings.TrimSpace(part)
+		if idx := strings.LastIndex(raw, ":"); idx >= 0 {
+			level, raw = raw[:idx], raw[idx+1:]
+		}
+		if v, err := strconv.ParseFloat(strings.TrimSpace(raw), 64); err == nil {
+			thresholds = append(thresholds, levelThreshold{level: level, value: v})
+		}
+	}
+	// 更严格的阈值代表更高的级别
+	sort.Slice(thresholds, func(i, j int) bool {
+		if operator == "<" || operator == "<=" {
+			return thresholds[i].value < thresholds[j].value
+		}
+		return thresholds[i].value > thresholds[j].value
+	})
+
+	for _, t := range thresholds {
+		var hit bool
+		switch operator {
+		case ">":
+			hit = value > t.value
+		case ">=":
+			hit = value >= t.value
+		case "<":
+			hit = value < t.value
+		case "<=":
+			hit = value <= t.value
+		case "=", "==":
+			hit = value == t.value
+		case "!=":
+			hit = value != t.value
+		}
+		if hit {
+			return t.level, true
+		}
+	}
+	return "", false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case int:
+		return float64(t), true
+	case string:
+		if f, err := strconv.ParseFloat(t, 64); err == nil {
+			return f, true
+		}
+	}
+	return 0, false
+}
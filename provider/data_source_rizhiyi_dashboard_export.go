@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"terraform-provider-rizhiyi/yottaweb"
+)
+
+func dataSourceDashboardExport() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceDashboardExportRead,
+
+		Schema: map[string]*schema.Schema{
+			"dashboard_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "ID of the dashboard to export.",
+			},
+			"output_file": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Path the export package is written to: JSON, or a gzipped tar when it ends in .tar.gz or .tgz. Usable as rizhiyi_dashboard.source_file.",
+			},
+			"content": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Export package as a JSON string.",
+			},
+			"trend_ids": &schema.Schema{
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Computed:    true,
+				Description: "IDs of the trends referenced by the dashboard tabs and included in the package.",
+			},
+		},
+	}
+}
+
+func dataSourceDashboardExportRead(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	dashboardID := d.Get("dashboard_id").(string)
+
+	pkg, err := exportDashboardPackage(c, dashboardID)
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(pkg)
+	if err != nil {
+		return err
+	}
+	if v, ok := d.GetOk("output_file"); ok {
+		if err := writeDashboardPackage(pkg, v.(string)); err != nil {
+			return fmt.Errorf("failed to write %s: %s", v.(string), err)
+		}
+	}
+
+	trendIDs := make([]string, 0, len(pkg.Trends))
+	for _, trend := range pkg.Trends {
+		trendIDs = append(trendIDs, idString(trend["id"]))
+	}
+
+	d.SetId(dashboardID)
+	d.Set("content", string(content))
+	d.Set("trend_ids", trendIDs)
+	return nil
+}
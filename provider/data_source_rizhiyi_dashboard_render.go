@@ -0,0 +1,125 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"terraform-provider-rizhiyi/yottaweb"
+)
+
+func dataSourceDashboardRender() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceDashboardRenderRead,
+
+		Schema: map[string]*schema.Schema{
+			"dashboard_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "ID of the dashboard to render.",
+			},
+			"tab_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "ID of the tab to render. Defaults to the first tab.",
+			},
+			"format": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      yottaweb.RenderFormatPNG,
+				ValidateFunc: validation.StringInSlice([]string{yottaweb.RenderFormatPNG, yottaweb.RenderFormatPDF}, false),
+				Description:  "Output format: png or pdf. (default value png)",
+			},
+			"time_range": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Time range the widgets are rendered over, for example: -1h,now. Defaults to the time ranges saved in the tab.",
+			},
+			"width": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      1600,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Viewport width in pixels. (default value 1600)",
+			},
+			"height": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Viewport height in pixels. Defaults to the full height of the tab.",
+			},
+			"timeout": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      120,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Seconds the render service waits for the widgets to finish loading. (default value 120)",
+			},
+			"output_file": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Path the rendered file is written to. Missing parent directories are created.",
+			},
+			"content_type": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Content type returned by the render service.",
+			},
+			"size": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Size of the rendered file in bytes.",
+			},
+			"sha256": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Hex SHA-256 of the rendered file.",
+			},
+		},
+	}
+}
+
+func dataSourceDashboardRenderRead(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	dashboardID := d.Get("dashboard_id").(string)
+	tabID := d.Get("tab_id").(string)
+	outputFile := d.Get("output_file").(string)
+
+	result, err := c.RenderDashboard(yottaweb.RenderRequest{
+		DashboardID: dashboardID,
+		TabID:       tabID,
+		Format:      d.Get("format").(string),
+		TimeRange:   d.Get("time_range").(string),
+		Width:       d.Get("width").(int),
+		Height:      d.Get("height").(int),
+		Timeout:     d.Get("timeout").(int),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render dashboard %s: %s", dashboardID, err)
+	}
+	if len(result.Body) == 0 {
+		return fmt.Errorf("render of dashboard %s returned an empty file", dashboardID)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputFile, result.Body, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %s", outputFile, err)
+	}
+
+	sum := sha256.Sum256(result.Body)
+	id := dashboardID
+	if tabID != "" {
+		id = dashboardID + "/" + tabID
+	}
+	d.SetId(id)
+	d.Set("content_type", result.ContentType)
+	d.Set("size", len(result.Body))
+	d.Set("sha256", hex.EncodeToString(sum[:]))
+	return nil
+}
@@ -0,0 +1,259 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"terraform-provider-rizhiyi/yottaweb"
+)
+
+// indexStatsTiers maps the storage tier outputs to the size keys of the stats API.
+var indexStatsTiers = map[string]string{
+	"hot_size_bytes":    "hot_size",
+	"warm_size_bytes":   "hdd_size",
+	"cold_size_bytes":   "nas_size",
+	"frozen_size_bytes": "freeze_size",
+}
+
+func dataSourceIndexStats() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceIndexStatsRead,
+
+		Schema: map[string]*schema.Schema{
+			"index_id": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"index_name"},
+				Description:   "ID of the index, for example rizhiyi_index.x.id. Leave index_id and index_name empty to read every index.",
+			},
+			"index_name": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"index_id"},
+				Description:   "Name of the index, resolved to its ID.",
+			},
+			"total_doc_count": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of documents across the returned indexes.",
+			},
+			"total_size_bytes": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Storage size across the returned indexes and tiers, in bytes.",
+			},
+			"indexes": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Usage of each returned index.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": &schema.Schema{
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "ID of the index.",
+						},
+						"name": &schema.Schema{
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Name of the index.",
+						},
+						"doc_count": &schema.Schema{
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Number of documents in the index.",
+						},
+						"size_bytes": &schema.Schema{
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Storage size of the index across all tiers, in bytes.",
+						},
+						"hot_size_bytes": &schema.Schema{
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Storage size on the hot (SSD) tier, in bytes.",
+						},
+						"warm_size_bytes": &schema.Schema{
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Storage size on the warm (HDD) tier, in bytes.",
+						},
+						"cold_size_bytes": &schema.Schema{
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Storage size on the cold (NAS) tier, in bytes.",
+						},
+						"frozen_size_bytes": &schema.Schema{
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Storage size of frozen data, in bytes.",
+						},
+						"daily_ingest_bytes": &schema.Schema{
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Volume ingested into the index over the last 24 hours, in bytes.",
+						},
+						"oldest_event_time": &schema.Schema{
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Time of the oldest event (RFC 3339), empty if the index is empty.",
+						},
+						"newest_event_time": &schema.Schema{
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Time of the newest event (RFC 3339), empty if the index is empty.",
+						},
+						"shards": &schema.Schema{
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Number of primary shards.",
+						},
+						"replicas": &schema.Schema{
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Number of replica shards.",
+						},
+						"unassigned_shards": &schema.Schema{
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Number of primary or replica shards not allocated to a node.",
+						},
+						"health": &schema.Schema{
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Shard health of the index as reported by the platform: green (all allocated), yellow (replicas missing) or red (primaries missing). Empty when the platform does not report it.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceIndexStatsRead(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	id := d.Get("index_id").(string)
+	if name := d.Get("index_name").(string); name != "" {
+		rid, err := c.GetResourceIdByName(name, "..", "v3", "indexes")
+		if err != nil {
+			return err
+		}
+		if rid == "" {
+			return fmt.Errorf("index %q not found", name)
+		}
+		id = rid
+	}
+
+	var targets []map[string]interface{}
+	if id != "" {
+		index, err := c.GetResourceById(id, "..", "v3", "indexes")
+		if err != nil {
+			return fmt.Errorf("failed to read index %s: %s", id, err)
+		}
+		targets = append(targets, index)
+	} else {
+		all, err := listResources(c, "..", "v3", "indexes")
+		if err != nil {
+			return err
+		}
+		targets = all
+	}
+
+	indexes := make([]map[string]interface{}, 0, len(targets))
+	totalDocs, totalSize := 0, 0
+	for _, index := range targets {
+		indexID := idString(index["id"])
+		if indexID == "" {
+			indexID = id
+		}
+		stats, err := getIndexStats(c, indexID)
+		if err != nil {
+			return err
+		}
+		entry := flattenIndexStats(indexID, stringValue(index["name"]), stats)
+		totalDocs += entry["doc_count"].(int)
+		totalSize += entry["size_bytes"].(int)
+		indexes = append(indexes, entry)
+	}
+
+	if id != "" {
+		d.SetId(id)
+	} else {
+		d.SetId("all")
+	}
+	d.Set("index_id", id)
+	d.Set("total_doc_count", totalDocs)
+	d.Set("total_size_bytes", totalSize)
+	if err := d.Set("indexes", indexes); err != nil {
+		return err
+	}
+	return nil
+}
+
+// getIndexStats reads the usage of an index, which carries no id or name field.
+func getIndexStats(c *yottaweb.Client, id string) (map[string]interface{}, error) {
+	endpoint := c.BuildRizhiyiURL(nil, "..", "v3", "indexes", id, "stats")
+	resp, err := c.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stats of index %s: %s", id, err)
+	}
+	defer resp.Body.Close()
+
+	var data map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+	if obj, ok := data["object"].(map[string]interface{}); ok {
+		return obj, nil
+	}
+	return data, nil
+}
+
+func flattenIndexStats(id, name string, stats map[string]interface{}) map[string]interface{} {
+	entry := map[string]interface{}{
+		"id":                 id,
+		"name":               name,
+		"doc_count":          0,
+		"size_bytes":         0,
+		"daily_ingest_bytes": 0,
+		"oldest_event_time":  indexStatsTime(stats["min_timestamp"]),
+		"newest_event_time":  indexStatsTime(stats["max_timestamp"]),
+		"shards":             0,
+		"replicas":           0,
+		"unassigned_shards":  0,
+		"health":             stringValue(stats["health"]),
+	}
+	if name == "" {
+		entry["name"] = stringValue(stats["name"])
+	}
+	for out, key := range map[string]string{
+		"doc_count":          "doc_count",
+		"daily_ingest_bytes": "ingest_bytes_1d",
+		"shards":             "primary_shards",
+		"replicas":           "replica_shards",
+		"unassigned_shards":  "unassigned_shards",
+	} {
+		if v, ok := toInt(stats[key]); ok {
+			entry[out] = v
+		}
+	}
+	size := 0
+	for out, key := range indexStatsTiers {
+		v, _ := toInt(stats[key])
+		entry[out] = v
+		size += v
+	}
+	entry["size_bytes"] = size
+	return entry
+}
+
+// indexStatsTime formats a millisecond timestamp as RFC 3339.
+func indexStatsTime(v interface{}) string {
+	if ms, ok := toInt(v); ok && ms > 0 {
+		return time.Unix(0, int64(ms)*int64(time.Millisecond)).UTC().Format(time.RFC3339)
+	}
+	return stringValue(v)
+}
@@ -0,0 +1,232 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"terraform-provider-rizhiyi/yottaweb"
+)
+
+func dataSourceParserRuleTest() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceParserRuleTestRead,
+
+		Schema: map[string]*schema.Schema{
+			"sample_lines": &schema.Schema{
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Required:    true,
+				MinItems:    1,
+				Description: "Raw log lines to parse.",
+			},
+			"conf": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"conf", "step", "parser_rule_id"},
+				ValidateFunc: validation.StringIsJSON,
+				Description:  "Parsing rules to test, in the format of rizhiyi_parser_rule.conf.",
+			},
+			"step": parserRuleTestStepSchema(),
+			"parser_rule_id": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"conf", "step", "parser_rule_id"},
+				Description:  "ID of a saved parser rule to test.",
+			},
+			"logtype": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Log type the lines are parsed as. Defaults to the logtype of parser_rule_id.",
+			},
+			"expected_fields": &schema.Schema{
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Fields each line must produce; fields not listed are not checked. A mismatch fails the read, which happens at plan time unless the inputs reference resources with pending changes, in which case it happens during apply.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"line": &schema.Schema{
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validation.IntAtLeast(0),
+							Description:  "Index of the line in sample_lines, starting at 0.",
+						},
+						"fields": &schema.Schema{
+							Type:        schema.TypeMap,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Required:    true,
+							Description: "Expected field values keyed by field name; nested fields use dotted names, for example json.status.",
+						},
+					},
+				},
+			},
+			"results": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "What the rules extracted from each line, in the order of sample_lines.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"fields": &schema.Schema{
+							Type:        schema.TypeMap,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Computed:    true,
+							Description: "Extracted field values keyed by dotted field name; lists are JSON encoded.",
+						},
+						"error": &schema.Schema{
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Parse error of the line, empty if it parsed.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceParserRuleTestRead(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	conf := d.Get("conf").(string)
+	if steps := d.Get("step").([]interface{}); len(steps) > 0 {
+		v, err := expandParserRuleSteps(steps)
+		if err != nil {
+			return err
+		}
+		conf = v
+	}
+	logtype := d.Get("logtype").(string)
+	if id := d.Get("parser_rule_id").(string); id != "" {
+		rule, err := c.GetResourceById(id, "v3", "parserrules")
+		if err != nil {
+			return fmt.Errorf("failed to read parser rule %s: %s", id, err)
+		}
+		if conf, err = parserRuleTestConf(rule["conf"]); err != nil {
+			return fmt.Errorf("parser rule %s: %s", id, err)
+		}
+		if logtype == "" {
+			logtype = stringValue(rule["logtype"])
+		}
+	}
+
+	lines := make([]string, 0)
+	for _, v := range d.Get("sample_lines").([]interface{}) {
+		s, _ := v.(string)
+		lines = append(lines, s)
+	}
+
+	previews, err := c.PreviewParse(conf, logtype, lines)
+	if err != nil {
+		return fmt.Errorf("parser rule preview failed: %s", err)
+	}
+	results := make([]map[string]interface{}, 0, len(previews))
+	extracted := make([]map[string]string, 0, len(previews))
+	for _, p := range previews {
+		fields := map[string]string{}
+		flattenParsedFields("", p.Fields, fields)
+		values := map[string]interface{}{}
+		for k, v := range fields {
+			values[k] = v
+		}
+		results = append(results, map[string]interface{}{"fields": values, "error": p.Error})
+		extracted = append(extracted, fields)
+	}
+
+	h := sha256.New()
+	h.Write([]byte(logtype + "\n" + conf))
+	for _, line := range lines {
+		h.Write([]byte("\n" + line))
+	}
+	d.SetId(hex.EncodeToString(h.Sum(nil)))
+	if err := d.Set("results", results); err != nil {
+		return err
+	}
+
+	return checkParsedFields(d.Get("expected_fields").([]interface{}), extracted)
+}
+
+// parserRuleTestStepSchema is the step block of rizhiyi_parser_rule, so steps can
+// be tested before they are saved.
+func parserRuleTestStepSchema() *schema.Schema {
+	s := parserRuleStepSchema()
+	s.ExactlyOneOf = []string{"conf", "step", "parser_rule_id"}
+	s.Description = "Parser steps to test, in the format of rizhiyi_parser_rule.step."
+	return s
+}
+
+// parserRuleTestConf returns the conf of a saved rule as a string; the platform
+// returns it either as a JSON string or as the decoded list.
+func parserRuleTestConf(v interface{}) (string, error) {
+	conf, ok := v.(string)
+	if !ok && v != nil {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		conf = string(b)
+	}
+	if conf == "" {
+		return "", fmt.Errorf("parser rule has no conf")
+	}
+	return conf, nil
+}
+
+// flattenParsedFields flattens nested field objects into dotted names.
+func flattenParsedFields(prefix string, fields map[string]interface{}, out map[string]string) {
+	for k, v := range fields {
+		name := k
+		if prefix != "" {
+			name = prefix + "." + k
+		}
+		switch val := v.(type) {
+		case map[string]interface{}:
+			flattenParsedFields(name, val, out)
+		case string:
+			out[name] = val
+		case nil:
+			out[name] = ""
+		case []interface{}:
+			b, _ := json.Marshal(val)
+			out[name] = string(b)
+		default:
+			out[name] = fmt.Sprintf("%v", val)
+		}
+	}
+}
+
+// checkParsedFields compares the expected blocks with the extracted fields and
+// reports every mismatch at once.
+func checkParsedFields(expected []interface{}, extracted []map[string]string) error {
+	var mismatches []string
+	for _, raw := range expected {
+		e := raw.(map[string]interface{})
+		line := e["line"].(int)
+		if line >= len(extracted) {
+			mismatches = append(mismatches, fmt.Sprintf("line %d: no such sample line", line))
+			continue
+		}
+		want := e["fields"].(map[string]interface{})
+		names := make([]string, 0, len(want))
+		for k := range want {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			got, ok := extracted[line][k]
+			switch {
+			case !ok:
+				mismatches = append(mismatches, fmt.Sprintf("line %d: field %s missing, want %q", line, k, want[k]))
+			case got != want[k].(string):
+				mismatches = append(mismatches, fmt.Sprintf("line %d: field %s = %q, want %q", line, k, got, want[k]))
+			}
+		}
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("parser rule test failed:\n%s", strings.Join(mismatches, "\n"))
+	}
+	return nil
+}
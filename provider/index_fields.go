@@ -0,0 +1,162 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+var (
+	indexFieldTypes       = []string{"string", "long", "double", "ip", "date"}
+	indexFieldNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+)
+
+// indexPatternFieldTypes lists the field types each index pattern accepts;
+// patterns not listed accept every type.
+var indexPatternFieldTypes = map[string][]string{
+	"kNumeric": {"long", "double"},
+}
+
+func indexFieldSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:          schema.TypeList,
+		Optional:      true,
+		ConflictsWith: []string{"tokenizer", "discard_stored_field"},
+		Description:   "Field mapping of the index, checked against pattern (kNumeric only allows long and double fields). Changes apply to partitions created after the update. Removing every block clears the mapping unless tokenizer or discard_stored_field is set. Replaces tokenizer and discard_stored_field.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"name": &schema.Schema{
+					Type:         schema.TypeString,
+					Required:     true,
+					ValidateFunc: validation.StringMatch(indexFieldNamePattern, "must start with a letter or underscore and contain only letters, digits, underscores and dots"),
+					Description:  "Name of the field, for example: json.status.",
+				},
+				"type": &schema.Schema{
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      "string",
+					ValidateFunc: validation.StringInSlice(indexFieldTypes, false),
+					Description:  "Type of the field: string, long, double, ip or date. (default value string)",
+				},
+				"tokenized": &schema.Schema{
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     false,
+					Description: "Whether the field is split with the standard tokenizer. Only string fields can be tokenized. (default value false)",
+				},
+				"stored": &schema.Schema{
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     true,
+					Description: "Whether the original value is stored and returned in search results. (default value true)",
+				},
+				"doc_values": &schema.Schema{
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     true,
+					Description: "Whether column values are kept for sorting and aggregation. (default value true)",
+				},
+			},
+		},
+	}
+}
+
+// checkIndexFields verifies the field blocks against each other and against the
+// index pattern. An empty pattern skips the pattern check.
+func checkIndexFields(pattern string, fields []interface{}) error {
+	seen := map[string]bool{}
+	for i, raw := range fields {
+		f, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name := f["name"].(string)
+		typ := f["type"].(string)
+		if name == "" {
+			continue
+		}
+		if seen[name] {
+			return fmt.Errorf("field %d: %s is declared more than once", i, name)
+		}
+		seen[name] = true
+		if f["tokenized"].(bool) && typ != "string" {
+			return fmt.Errorf("field %s: only string fields can be tokenized, got type %s", name, typ)
+		}
+		if allowed, ok := indexPatternFieldTypes[pattern]; ok && typ != "" {
+			if !stringInList(typ, allowed) {
+				return fmt.Errorf("field %s: pattern %s does not allow %s fields, allowed types: %v", name, pattern, typ, allowed)
+			}
+		}
+	}
+	return nil
+}
+
+func stringInList(s string, list []string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// resourceIndexFieldDiff checks the field blocks at plan time.
+func resourceIndexFieldDiff(d *schema.ResourceDiff, m interface{}) error {
+	pattern := ""
+	if d.NewValueKnown("pattern") {
+		pattern = d.Get("pattern").(string)
+	}
+	return checkIndexFields(pattern, d.Get("field").([]interface{}))
+}
+
+// expandIndexFields builds the fields payload of an index request.
+func expandIndexFields(fields []interface{}) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(fields))
+	for _, raw := range fields {
+		f := raw.(map[string]interface{})
+		field := map[string]interface{}{
+			"name":       f["name"].(string),
+			"type":       f["type"].(string),
+			"tokenizer":  "",
+			"store":      f["stored"].(bool),
+			"doc_values": f["doc_values"].(bool),
+		}
+		if f["tokenized"].(bool) {
+			field["tokenizer"] = "standard"
+		}
+		result = append(result, field)
+	}
+	return result
+}
+
+// flattenIndexFields maps the fields returned by the index API onto field blocks.
+func flattenIndexFields(v interface{}) []interface{} {
+	list, _ := v.([]interface{})
+	result := make([]interface{}, 0, len(list))
+	for _, raw := range list {
+		f, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		field := map[string]interface{}{
+			"name":       stringValue(f["name"]),
+			"type":       stringValue(f["type"]),
+			"tokenized":  stringValue(f["tokenizer"]) != "",
+			"stored":     true,
+			"doc_values": true,
+		}
+		if field["type"] == "" {
+			field["type"] = "string"
+		}
+		if b, ok := f["store"].(bool); ok {
+			field["stored"] = b
+		}
+		if b, ok := f["doc_values"].(bool); ok {
+			field["doc_values"] = b
+		}
+		result = append(result, field)
+	}
+	return result
+}
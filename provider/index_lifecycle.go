@@ -0,0 +1,318 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// The block is named storage_lifecycle because lifecycle is a reserved
+// Terraform meta-argument.
+
+var indexDurationPattern = regexp.MustCompile(`^(\d+)([hdwMy])$`)
+
+// parseIndexDuration parses the retention durations the index API uses, such as
+// 12h, 7d, 2w, 3M or 1y.
+func parseIndexDuration(s string) (time.Duration, error) {
+	m := indexDurationPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("invalid duration %q, expected a number followed by h, d, w, M or y", s)
+	}
+	n, _ := strconv.Atoi(m[1])
+	day := 24 * time.Hour
+	unit := map[string]time.Duration{"h": time.Hour, "d": day, "w": 7 * day, "M": 30 * day, "y": 365 * day}[m[2]]
+	return time.Duration(n) * unit, nil
+}
+
+func validateIndexDuration(i interface{}, k string) (warnings []string, errors []error) {
+	v, ok := i.(string)
+	if !ok {
+		errors = append(errors, fmt.Errorf("expected type of %s to be string", k))
+		return
+	}
+	if _, err := parseIndexDuration(v); err != nil {
+		errors = append(errors, fmt.Errorf("%s: %s", k, err))
+	}
+	return
+}
+
+func indexLifecycleSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:          schema.TypeList,
+		Optional:      true,
+		MaxItems:      1,
+		ConflictsWith: []string{"sink_to_hdd", "sink_to_nas", "freeze", "advanced_strategy", "discard_backup"},
+		Description:   "Hot/warm/cold storage tiers of the index. Every move must happen before expired_time, in the order warm, cold, freeze. Replaces sink_to_hdd, sink_to_nas, freeze, advanced_strategy and discard_backup; removing the block clears the tiers unless those fields are set instead.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"hot_days": &schema.Schema{
+					Type:        schema.TypeInt,
+					Optional:    true,
+					Description: "Days data stays on the hot (SSD) tier before moving to the warm tier; shorthand for warm.after in days.",
+				},
+				"warm": &schema.Schema{
+					Type:        schema.TypeList,
+					Optional:    true,
+					MaxItems:    1,
+					Description: "Move to the warm tier.",
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"after": &schema.Schema{
+								Type:             schema.TypeString,
+								Optional:         true,
+								ValidateFunc:     validateIndexDuration,
+								DiffSuppressFunc: suppressEquivalentIndexDuration,
+								Description:      "Age at which data moves to the warm tier, for example: 7d. Conflicts with hot_days.",
+							},
+							"target": &schema.Schema{
+								Type:        schema.TypeString,
+								Optional:    true,
+								Default:     "hdd",
+								Description: "Storage the warm tier lives on. Kept in advanced_strategy as sink_to_hdd_target, a key the provider defines; the platform is not known to act on it. (default value hdd)",
+							},
+						},
+					},
+				},
+				"cold": &schema.Schema{
+					Type:        schema.TypeList,
+					Optional:    true,
+					MaxItems:    1,
+					Description: "Move to the cold tier on NAS.",
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"after": &schema.Schema{
+								Type:             schema.TypeString,
+								Required:         true,
+								ValidateFunc:     validateIndexDuration,
+								DiffSuppressFunc: suppressEquivalentIndexDuration,
+								Description:      "Age at which data moves to NAS, for example: 30d.",
+							},
+							"path": &schema.Schema{
+								Type:        schema.TypeString,
+								Optional:    true,
+								Description: "NAS mount path of the cold tier. Kept in advanced_strategy as sink_to_nas_path, a key the provider defines; the platform is not known to act on it and uses its own setting.",
+							},
+						},
+					},
+				},
+				"freeze": &schema.Schema{
+					Type:        schema.TypeList,
+					Optional:    true,
+					MaxItems:    1,
+					Description: "Freeze data, keeping it on disk but out of searches until thawed.",
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"after": &schema.Schema{
+								Type:             schema.TypeString,
+								Required:         true,
+								ValidateFunc:     validateIndexDuration,
+								DiffSuppressFunc: suppressEquivalentIndexDuration,
+								Description:      "Age at which data is frozen, for example: 60d.",
+							},
+						},
+					},
+				},
+				"discard_backup": &schema.Schema{
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     false,
+					Description: "Whether replicas are dropped when data leaves the hot tier. (default value false)",
+				},
+			},
+		},
+	}
+}
+
+// indexLifecycle is the flattened storage_lifecycle block.
+type indexLifecycle struct {
+	HotDays       int
+	WarmAfter     string
+	WarmTarget    string
+	ColdAfter     string
+	ColdPath      string
+	FreezeAfter   string
+	DiscardBackup bool
+}
+
+func firstBlock(v interface{}) map[string]interface{} {
+	if list, ok := v.([]interface{}); ok && len(list) > 0 && list[0] != nil {
+		return list[0].(map[string]interface{})
+	}
+	return nil
+}
+
+func expandIndexLifecycle(v interface{}) *indexLifecycle {
+	block := firstBlock(v)
+	if block == nil {
+		return nil
+	}
+	lc := &indexLifecycle{
+		HotDays:       block["hot_days"].(int),
+		DiscardBackup: block["discard_backup"].(bool),
+	}
+	if warm := firstBlock(block["warm"]); warm != nil {
+		lc.WarmAfter = warm["after"].(string)
+		lc.WarmTarget = warm["target"].(string)
+	}
+	if cold := firstBlock(block["cold"]); cold != nil {
+		lc.ColdAfter = cold["after"].(string)
+		lc.ColdPath = cold["path"].(string)
+	}
+	if freeze := firstBlock(block["freeze"]); freeze != nil {
+		lc.FreezeAfter = freeze["after"].(string)
+	}
+	return lc
+}
+
+// warmAfter is the age data moves to the warm tier, from warm.after or hot_days.
+func (lc *indexLifecycle) warmAfter() string {
+	if lc.WarmAfter == "" && lc.HotDays > 0 {
+		return strconv.Itoa(lc.HotDays) + "d"
+	}
+	return lc.WarmAfter
+}
+
+// check verifies the tiers are ordered and all happen before expiredTime.
+// Unknown (empty) values are skipped.
+func (lc *indexLifecycle) check(expiredTime string) error {
+	if lc.HotDays > 0 && lc.WarmAfter != "" {
+		return fmt.Errorf("storage_lifecycle: set either hot_days or warm.after, not both")
+	}
+	steps := []struct{ name, after string }{
+		{"warm.after", lc.warmAfter()},
+		{"cold.after", lc.ColdAfter},
+		{"freeze.after", lc.FreezeAfter},
+	}
+	var prevName string
+	var prev time.Duration
+	for _, step := range steps {
+		if step.after == "" {
+			continue
+		}
+		d, err := parseIndexDuration(step.after)
+		if err != nil {
+			return fmt.Errorf("storage_lifecycle %s: %s", step.name, err)
+		}
+		if prevName != "" && d <= prev {
+			return fmt.Errorf("storage_lifecycle: %s (%s) must be later than %s", step.name, step.after, prevName)
+		}
+		prevName, prev = step.name, d
+	}
+	if prevName == "" || expiredTime == "" {
+		return nil
+	}
+	expired, err := parseIndexDuration(expiredTime)
+	if err != nil {
+		return fmt.Errorf("expired_time: %s", err)
+	}
+	if prev >= expired {
+		return fmt.Errorf("storage_lifecycle: %s must be earlier than expired_time (%s), or the data expires first", prevName, expiredTime)
+	}
+	return nil
+}
+
+// resourceIndexLifecycleDiff checks storage_lifecycle against expired_time at plan time.
+func resourceIndexLifecycleDiff(d *schema.ResourceDiff, m interface{}) error {
+	lc := expandIndexLifecycle(d.Get("storage_lifecycle"))
+	if lc == nil {
+		return nil
+	}
+	return lc.check(d.Get("expired_time").(string))
+}
+
+// setIndexStorageFields fills the storage tier fields of an index request, from
+// storage_lifecycle when it is set and from the flat attributes otherwise.
+func setIndexStorageFields(d *schema.ResourceData, requestBody map[string]interface{}) error {
+	lc := expandIndexLifecycle(d.Get("storage_lifecycle"))
+	if lc == nil {
+		// 始终发送，从配置中删除的字段才会在平台上被清空
+		for _, k := range []string{"sink_to_hdd", "sink_to_nas", "freeze", "advanced_strategy"} {
+			requestBody[k] = d.Get(k).(string)
+		}
+		requestBody["discard_backup"] = "false"
+		if v := d.Get("discard_backup").(string); v != "" {
+			requestBody["discard_backup"] = v
+		}
+		return nil
+	}
+	if err := lc.check(d.Get("expired_time").(string)); err != nil {
+		return err
+	}
+	// hot_days、sink_to_hdd_target 和 sink_to_nas_path 不是平台文档中的
+	// advanced_strategy 字段，只是随 advanced_strategy 保存以便回读。hot_days 已经
+	// 通过 sink_to_hdd 生效；target 和 path 未经平台确认会被使用。
+	strategy := map[string]interface{}{}
+	if lc.HotDays > 0 {
+		strategy["hot_days"] = lc.HotDays
+	}
+	if lc.WarmTarget != "" {
+		strategy["sink_to_hdd_target"] = lc.WarmTarget
+	}
+	if lc.ColdPath != "" {
+		strategy["sink_to_nas_path"] = lc.ColdPath
+	}
+	b, err := json.Marshal(strategy)
+	if err != nil {
+		return err
+	}
+	requestBody["sink_to_hdd"] = lc.warmAfter()
+	requestBody["sink_to_nas"] = lc.ColdAfter
+	requestBody["freeze"] = lc.FreezeAfter
+	requestBody["advanced_strategy"] = string(b)
+	requestBody["discard_backup"] = strconv.FormatBool(lc.DiscardBackup)
+	return nil
+}
+
+// flattenIndexLifecycle maps the storage tier fields of an index back onto the
+// storage_lifecycle block.
+func flattenIndexLifecycle(data map[string]interface{}) []interface{} {
+	var strategy map[string]interface{}
+	if s := indexAdvancedStrategy(data["advanced_strategy"]); s != "" {
+		_ = json.Unmarshal([]byte(s), &strategy)
+	}
+	block := map[string]interface{}{
+		"hot_days":       0,
+		"warm":           []interface{}{},
+		"cold":           []interface{}{},
+		"freeze":         []interface{}{},
+		"discard_backup": indexBool(data["discard_backup"]),
+	}
+	after := stringValue(data["sink_to_hdd"])
+	if v, ok := toInt(strategy["hot_days"]); ok && v > 0 {
+		// warm.after 由 hot_days 推出时不回写
+		block["hot_days"] = v
+		after = ""
+	}
+	// 配置了 warm 块时总会写入 sink_to_hdd_target，据此保留只设置了 target 的 warm 块
+	target, hasTarget := strategy["sink_to_hdd_target"].(string)
+	if target == "" {
+		target = "hdd"
+	}
+	if after != "" || hasTarget {
+		block["warm"] = []interface{}{map[string]interface{}{"after": after, "target": target}}
+	}
+	if after := stringValue(data["sink_to_nas"]); after != "" {
+		block["cold"] = []interface{}{map[string]interface{}{"after": after, "path": stringValue(strategy["sink_to_nas_path"])}}
+	}
+	if after := stringValue(data["freeze"]); after != "" {
+		block["freeze"] = []interface{}{map[string]interface{}{"after": after}}
+	}
+	return []interface{}{block}
+}
+
+// indexBool reads a flag the index API returns either as a bool or as a string.
+func indexBool(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		r, _ := strconv.ParseBool(b)
+		return r
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package provider
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"terraform-provider-rizhiyi/yottaweb"
+)
+
+// assign_data entries on the wire are "appname:tag", or "appname:tag:hostname"
+// when the assignment is limited to one host.
+var parserAssignmentPattern = regexp.MustCompile(`^[A-Za-z0-9_.*-]+$`)
+
+func parserRuleAssignmentSchema() *schema.Schema {
+	match := validation.StringMatch(parserAssignmentPattern, "may only contain letters, digits, _, ., - and the wildcard *")
+	return &schema.Schema{
+		Type:          schema.TypeList,
+		Optional:      true,
+		ConflictsWith: []string{"assign_data"},
+		Description:   "Sources the rule parses. Replaces assign_data. Pairs another parser rule also claims are planned in shared_assignments, or fail the plan with fail_on_shared_assignment.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"appname": &schema.Schema{
+					Type:         schema.TypeString,
+					Required:     true,
+					ValidateFunc: match,
+					Description:  "Appname of the events parsed, for example: nginx.",
+				},
+				"tag": &schema.Schema{
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      "*",
+					ValidateFunc: match,
+					Description:  "Tag of the events parsed. (default value *, any tag)",
+				},
+				"hostname": &schema.Schema{
+					Type:         schema.TypeString,
+					Optional:     true,
+					ValidateFunc: match,
+					Description:  "Limits the assignment to events from this host.",
+				},
+			},
+		},
+	}
+}
+
+type parserAssignment struct {
+	Appname  string
+	Tag      string
+	Hostname string
+}
+
+// key is the appname/tag pair two rules must not both claim.
+func (a parserAssignment) key() string {
+	return a.Appname + ":" + a.Tag
+}
+
+func (a parserAssignment) wire() string {
+	if a.Hostname != "" {
+		return a.key() + ":" + a.Hostname
+	}
+	return a.key()
+}
+
+func parseParserAssignment(s string) parserAssignment {
+	parts := strings.SplitN(strings.TrimSpace(s), ":", 3)
+	a := parserAssignment{Appname: parts[0], Tag: "*"}
+	if len(parts) > 1 && parts[1] != "" {
+		a.Tag = parts[1]
+	}
+	if len(parts) > 2 {
+		a.Hostname = parts[2]
+	}
+	return a
+}
+
+func expandParserAssignments(v interface{}) []parserAssignment {
+	list, _ := v.([]interface{})
+	result := make([]parserAssignment, 0, len(list))
+	for _, raw := range list {
+		b, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		result = append(result, parserAssignment{
+			Appname:  b["appname"].(string),
+			Tag:      b["tag"].(string),
+			Hostname: b["hostname"].(string),
+		})
+	}
+	return result
+}
+
+func flattenParserAssignments(v interface{}) []interface{} {
+	result := make([]interface{}, 0)
+	for _, s := range stringList(v) {
+		a := parseParserAssignment(s.(string))
+		result = append(result, map[string]interface{}{"appname": a.Appname, "tag": a.Tag, "hostname": a.Hostname})
+	}
+	return result
+}
+
+// parserRuleAssignData returns the assign_data sent to the platform, built from
+// assignment when it is set. It is empty when neither is configured, which
+// unassigns the rule.
+func parserRuleAssignData(d *schema.ResourceData) []interface{} {
+	assignments := expandParserAssignments(d.Get("assignment"))
+	if len(assignments) == 0 {
+		return d.Get("assign_data").([]interface{})
+	}
+	result := make([]interface{}, 0, len(assignments))
+	for _, a := range assignments {
+		result = append(result, a.wire())
+	}
+	return result
+}
+
+// sharedParserAssignments lists the appname/tag pairs of assignments that other
+// parser rules also claim, as "appname:tag (rule name)".
+func sharedParserAssignments(c *yottaweb.Client, id string, assignments []parserAssignment) ([]string, error) {
+	rules, err := listResources(c, "v3", "parserrules")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list parser rules: %s", err)
+	}
+	claimed := map[string]bool{}
+	for _, a := range assignments {
+		claimed[a.key()] = true
+	}
+	shared := make([]string, 0)
+	for _, rule := range rules {
+		if id != "" && idString(rule["id"]) == id {
+			continue
+		}
+		for _, s := range stringList(rule["assign_data"]) {
+			if key := parseParserAssignment(s.(string)).key(); claimed[key] {
+				shared = append(shared, fmt.Sprintf("%s (%s)", key, stringValue(rule["name"])))
+			}
+		}
+	}
+	sort.Strings(shared)
+	return shared, nil
+}
+
+// resourceParserRuleAssignmentDiff rejects duplicate assignments within the rule
+// and plans shared_assignments, so pairs other parser rules already claim show
+// in the plan. With fail_on_shared_assignment they fail it instead.
+func resourceParserRuleAssignmentDiff(d *schema.ResourceDiff, m interface{}) error {
+	failOnShared := d.Get("fail_on_shared_assignment").(bool)
+	if !d.NewValueKnown("assignment") || (!d.HasChange("assignment") && !failOnShared) {
+		return nil
+	}
+	assignments := expandParserAssignments(d.Get("assignment"))
+	seen := map[string]bool{}
+	for _, a := range assignments {
+		if seen[a.wire()] {
+			return fmt.Errorf("assignment %s is listed more than once", a.wire())
+		}
+		seen[a.wire()] = true
+	}
+	if len(assignments) == 0 {
+		if d.HasChange("assignment") {
+			return d.SetNew("shared_assignments", []string{})
+		}
+		return nil
+	}
+
+	name := d.Get("name").(string)
+	shared, err := sharedParserAssignments(m.(*yottaweb.Client), d.Id(), assignments)
+	if err != nil {
+		if failOnShared {
+			return fmt.Errorf("parser rule %s: cannot check shared assignments: %s", name, err)
+		}
+		// 仅用于提示，不阻塞 plan
+		log.Printf("[WARN] parser rule %s: %s", name, err)
+		return d.SetNewComputed("shared_assignments")
+	}
+	if failOnShared && len(shared) > 0 {
+		return fmt.Errorf("parser rule %s: assignments are also claimed by other parser rules: %s", name, strings.Join(shared, ", "))
+	}
+	for _, s := range shared {
+		log.Printf("[WARN] parser rule %s: %s is also claimed by another parser rule", name, s)
+	}
+	if old, _ := d.GetChange("shared_assignments"); strings.Join(stringsOf(old), ",") == strings.Join(shared, ",") {
+		return nil
+	}
+	return d.SetNew("shared_assignments", shared)
+}
+
+// stringsOf converts a list attribute to a []string.
+func stringsOf(v interface{}) []string {
+	result := make([]string, 0)
+	for _, s := range stringList(v) {
+		result = append(result, s.(string))
+	}
+	return result
+}
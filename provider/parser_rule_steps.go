@@ -0,0 +1,397 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// parserStepTypes are the typed sub-blocks of a step, in the order they are
+// documented; custom covers step types without a typed block.
+var parserStepTypes = []string{"json", "regex", "kv", "split", "timestamp", "geo", "rename", "add_fields", "custom"}
+
+func parserStepSourceSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Default:     "raw_message",
+		Description: "Field the step reads. (default value raw_message)",
+	}
+}
+
+func parserStepStringList(description string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Elem:        &schema.Schema{Type: schema.TypeString},
+		Optional:    true,
+		Description: description,
+	}
+}
+
+func parserStepBlock(description string, fields map[string]*schema.Schema) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		MaxItems:    1,
+		Description: description,
+		Elem:        &schema.Resource{Schema: fields},
+	}
+}
+
+func parserRuleStepSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeList,
+		Optional:     true,
+		ExactlyOneOf: []string{"conf", "step"},
+		Description:  "Parser steps, applied in order. Each step sets exactly one typed block. Serialized to conf.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"json": parserStepBlock("Parse a JSON field.", map[string]*schema.Schema{
+					"source": parserStepSourceSchema(),
+					"paths":  parserStepStringList("JSON paths extracted, for example: a.b. Extracts every path when empty."),
+					"extract_limit": &schema.Schema{
+						Type:         schema.TypeInt,
+						Optional:     true,
+						ValidateFunc: validation.IntAtLeast(0),
+						Description:  "Maximum number of fields extracted, 0 for no limit. (default value 0)",
+					},
+				}),
+				"regex": parserStepBlock("Extract fields with a regular expression.", map[string]*schema.Schema{
+					"source": parserStepSourceSchema(),
+					"pattern": &schema.Schema{
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: "Regular expression with named groups, for example: (?<ip>\\S+) (?<status>\\d+).",
+					},
+					"fields": parserStepStringList("Named groups kept as fields. Keeps every group when empty."),
+				}),
+				"kv": parserStepBlock("Split a field into key-value pairs.", map[string]*schema.Schema{
+					"source": parserStepSourceSchema(),
+					"field_split": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Default:     " ",
+						Description: "Separator between pairs. (default value a space)",
+					},
+					"value_split": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Default:     "=",
+						Description: "Separator between a key and its value. (default value =)",
+					},
+					"include": parserStepStringList("Keys kept. Keeps every key when empty."),
+					"exclude": parserStepStringList("Keys dropped."),
+				}),
+				"split": parserStepBlock("Split a field by a separator into named fields.", map[string]*schema.Schema{
+					"source": parserStepSourceSchema(),
+					"separator": &schema.Schema{
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: "Separator, for example: |.",
+					},
+					"names": parserStepStringList("Names of the resulting fields, in order."),
+				}),
+				"timestamp": parserStepBlock("Parse the event time from a field.", map[string]*schema.Schema{
+					"field": &schema.Schema{
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: "Field holding the time, for example: time.",
+					},
+					"format": &schema.Schema{
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: "Time format, for example: yyyy-MM-dd HH:mm:ss.SSS, or UNIX_MS.",
+					},
+					"timezone": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Time zone of times without an offset, for example: Asia/Shanghai. Defaults to the platform time zone.",
+					},
+				}),
+				"geo": parserStepBlock("Look up the location of an IP field.", map[string]*schema.Schema{
+					"source": &schema.Schema{
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: "Field holding the IP address.",
+					},
+				}),
+				"rename": parserStepBlock("Rename a field.", map[string]*schema.Schema{
+					"from": &schema.Schema{
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: "Current field name.",
+					},
+					"to": &schema.Schema{
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: "New field name.",
+					},
+				}),
+				"add_fields": parserStepBlock("Add constant fields.", map[string]*schema.Schema{
+					"fields": &schema.Schema{
+						Type:        schema.TypeMap,
+						Elem:        &schema.Schema{Type: schema.TypeString},
+						Required:    true,
+						Description: "Field values keyed by field name.",
+					},
+				}),
+				"custom": parserStepBlock("A step type without a typed block, given as its wire rule.", map[string]*schema.Schema{
+					"type": &schema.Schema{
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: "Step type, for example: syslog_pri.",
+					},
+					"rule": &schema.Schema{
+						Type:             schema.TypeString,
+						Required:         true,
+						ValidateFunc:     validation.StringIsJSON,
+						DiffSuppressFunc: suppressEquivalentJSON,
+						Description:      "Rule object of the step (JSON string).",
+					},
+				}),
+			},
+		},
+	}
+}
+
+func stringList(v interface{}) []interface{} {
+	list, _ := v.([]interface{})
+	result := make([]interface{}, 0, len(list))
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// expandParserStep builds the wire element of one step: {"<type>": {"rule": [rule]}}.
+func expandParserStep(i int, step map[string]interface{}) (map[string]interface{}, error) {
+	var stepType string
+	var rule map[string]interface{}
+	for _, t := range parserStepTypes {
+		b := firstBlock(step[t])
+		if b == nil {
+			continue
+		}
+		if stepType != "" {
+			return nil, fmt.Errorf("step %d: set only one of %s and %s", i, stepType, t)
+		}
+		stepType = t
+		switch t {
+		case "json":
+			limit := ""
+			if n := b["extract_limit"].(int); n > 0 {
+				limit = strconv.Itoa(n)
+			}
+			rule = map[string]interface{}{
+				"add_fields":    []interface{}{},
+				"source":        b["source"],
+				"another_name":  "",
+				"paths":         stringList(b["paths"]),
+				"extract_limit": limit,
+			}
+		case "regex":
+			rule = map[string]interface{}{"source": b["source"], "regex": b["pattern"], "fields": stringList(b["fields"])}
+		case "kv":
+			rule = map[string]interface{}{
+				"source":      b["source"],
+				"field_split": b["field_split"],
+				"value_split": b["value_split"],
+				"include":     stringList(b["include"]),
+				"exclude":     stringList(b["exclude"]),
+			}
+		case "split":
+			rule = map[string]interface{}{"source": b["source"], "separator": b["separator"], "names": stringList(b["names"])}
+		case "timestamp":
+			rule = map[string]interface{}{"source": b["field"], "format": b["format"], "timezone": b["timezone"]}
+		case "geo":
+			rule = map[string]interface{}{"source": b["source"]}
+		case "rename":
+			rule = map[string]interface{}{"source": b["from"], "target": b["to"]}
+		case "add_fields":
+			fields, _ := b["fields"].(map[string]interface{})
+			names := make([]string, 0, len(fields))
+			for k := range fields {
+				names = append(names, k)
+			}
+			sort.Strings(names)
+			list := make([]interface{}, 0, len(names))
+			for _, k := range names {
+				list = append(list, map[string]interface{}{"name": k, "value": fields[k]})
+			}
+			rule = map[string]interface{}{"fields": list}
+		case "custom":
+			stepType = b["type"].(string)
+			if err := json.Unmarshal([]byte(b["rule"].(string)), &rule); err != nil {
+				return nil, fmt.Errorf("step %d: custom.rule must be a JSON object: %s", i, err)
+			}
+		}
+	}
+	if stepType == "" {
+		return nil, fmt.Errorf("step %d: set one of %v", i, parserStepTypes)
+	}
+	return map[string]interface{}{stepType: map[string]interface{}{"rule": []interface{}{rule}}}, nil
+}
+
+// expandParserRuleSteps serializes the step list to the conf wire format.
+func expandParserRuleSteps(steps []interface{}) (string, error) {
+	conf := make([]interface{}, 0, len(steps))
+	for i, raw := range steps {
+		step, _ := raw.(map[string]interface{})
+		element, err := expandParserStep(i, step)
+		if err != nil {
+			return "", err
+		}
+		conf = append(conf, element)
+	}
+	b, err := json.Marshal(conf)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func emptyParserStep() map[string]interface{} {
+	step := map[string]interface{}{}
+	for _, t := range parserStepTypes {
+		step[t] = []interface{}{}
+	}
+	return step
+}
+
+// flattenParserRuleSteps maps a conf string back onto steps, one step per rule.
+// Rules of types without a typed block become custom steps.
+func flattenParserRuleSteps(conf string) ([]interface{}, error) {
+	var elements []map[string]map[string]interface{}
+	if err := json.Unmarshal([]byte(conf), &elements); err != nil {
+		return nil, fmt.Errorf("conf is not a list of parser steps: %s", err)
+	}
+	steps := make([]interface{}, 0, len(elements))
+	for _, element := range elements {
+		types := make([]string, 0, len(element))
+		for t := range element {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		for _, t := range types {
+			rules, _ := element[t]["rule"].([]interface{})
+			for _, raw := range rules {
+				rule, _ := raw.(map[string]interface{})
+				steps = append(steps, flattenParserStep(t, rule))
+			}
+		}
+	}
+	return steps, nil
+}
+
+func flattenParserStep(t string, rule map[string]interface{}) map[string]interface{} {
+	step := emptyParserStep()
+	var b map[string]interface{}
+	switch t {
+	case "json":
+		limit, _ := toInt(rule["extract_limit"])
+		b = map[string]interface{}{"source": stringValue(rule["source"]), "paths": stringList(rule["paths"]), "extract_limit": limit}
+	case "regex":
+		b = map[string]interface{}{"source": stringValue(rule["source"]), "pattern": stringValue(rule["regex"]), "fields": stringList(rule["fields"])}
+	case "kv":
+		b = map[string]interface{}{
+			"source":      stringValue(rule["source"]),
+			"field_split": stringValue(rule["field_split"]),
+			"value_split": stringValue(rule["value_split"]),
+			"include":     stringList(rule["include"]),
+			"exclude":     stringList(rule["exclude"]),
+		}
+	case "split":
+		b = map[string]interface{}{"source": stringValue(rule["source"]), "separator": stringValue(rule["separator"]), "names": stringList(rule["names"])}
+	case "timestamp":
+		b = map[string]interface{}{"field": stringValue(rule["source"]), "format": stringValue(rule["format"]), "timezone": stringValue(rule["timezone"])}
+	case "geo":
+		b = map[string]interface{}{"source": stringValue(rule["source"])}
+	case "rename":
+		b = map[string]interface{}{"from": stringValue(rule["source"]), "to": stringValue(rule["target"])}
+	case "add_fields":
+		fields := map[string]interface{}{}
+		list, _ := rule["fields"].([]interface{})
+		for _, raw := range list {
+			if f, ok := raw.(map[string]interface{}); ok {
+				fields[stringValue(f["name"])] = stringValue(f["value"])
+			}
+		}
+		b = map[string]interface{}{"fields": fields}
+	default:
+		r, _ := json.Marshal(rule)
+		step["custom"] = []interface{}{map[string]interface{}{"type": t, "rule": string(r)}}
+		return step
+	}
+	step[t] = []interface{}{b}
+	return step
+}
+
+// parserConfEqual compares two conf strings semantically.
+func parserConfEqual(a, b string) bool {
+	var ja, jb interface{}
+	if json.Unmarshal([]byte(a), &ja) != nil || json.Unmarshal([]byte(b), &jb) != nil {
+		return a == b
+	}
+	return reflect.DeepEqual(ja, jb)
+}
+
+// parserRuleConf returns the conf sent to the platform, serialized from step
+// when it is set.
+func parserRuleConf(d *schema.ResourceData) (string, error) {
+	if steps := d.Get("step").([]interface{}); len(steps) > 0 {
+		return expandParserRuleSteps(steps)
+	}
+	return d.Get("conf").(string), nil
+}
+
+// resourceParserRuleStepDiff plans the conf the steps serialize to, so the plan
+// shows the conf that will be sent. It is only unknown when a step is.
+func resourceParserRuleStepDiff(d *schema.ResourceDiff, m interface{}) error {
+	steps := d.Get("step").([]interface{})
+	if len(steps) == 0 || !d.HasChange("step") {
+		return nil
+	}
+	if !d.NewValueKnown("step") {
+		return d.SetNewComputed("conf")
+	}
+	conf, err := expandParserRuleSteps(steps)
+	if err != nil {
+		return err
+	}
+	if old, _ := d.GetChange("conf"); parserConfEqual(old.(string), conf) {
+		return nil
+	}
+	return d.SetNew("conf", conf)
+}
+
+// setParserRuleConf stores the conf read from the platform, and the steps when
+// they are configured; steps are only rewritten when the conf they serialize to
+// differs semantically from the platform's.
+func setParserRuleConf(d *schema.ResourceData, v interface{}) error {
+	conf, ok := v.(string)
+	if !ok && v != nil {
+		b, _ := json.Marshal(v)
+		conf = string(b)
+	}
+	d.Set("conf", conf)
+
+	steps := d.Get("step").([]interface{})
+	if len(steps) == 0 {
+		return nil
+	}
+	if current, err := expandParserRuleSteps(steps); err == nil && parserConfEqual(current, conf) {
+		return nil
+	}
+	flattened, err := flattenParserRuleSteps(conf)
+	if err != nil {
+		return err
+	}
+	return d.Set("step", flattened)
+}
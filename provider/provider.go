@@ -21,14 +21,37 @@ func Provider() *schema.Provider {
 				Description: "Rizhiyi authorization token (Base64 encoded username:password)",
 				Sensitive:   true,
 			},
+			"render_endpoint": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("RIZHIYI_RENDER_ENDPOINT", ""),
+				Description: "URL of the dashboard render service used by rizhiyi_dashboard_render; defaults to the platform's report rendering endpoint. Point it at a local stub in tests. Credentials are only sent when it is on the platform host.",
+			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"rizhiyi_role":        resourceRoles(),
-			"rizhiyi_index":       resourceIndex(),
-			"rizhiyi_dashboard":   resourceDashboards(),
-			"rizhiyi_alert":       resourceAlert(),
-			"rizhiyi_parser_rule": resourceParserRule(),
-			"rizhiyi_account":     resourceAccount(),
+			"rizhiyi_role":                 resourceRoles(),
+			"rizhiyi_index":                resourceIndex(),
+			"rizhiyi_index_set":            resourceIndexSet(),
+			"rizhiyi_index_match_rule":     resourceIndexMatchRule(),
+			"rizhiyi_dashboard":            resourceDashboards(),
+			"rizhiyi_dashboard_tab":        resourceDashboardTab(),
+			"rizhiyi_dashboard_permission": resourceDashboardPermission(),
+			"rizhiyi_trend":                resourceTrend(),
+			"rizhiyi_report":               resourceReport(),
+			"rizhiyi_saved_search":         resourceSavedSearch(),
+			"rizhiyi_alert":                resourceAlert(),
+			"rizhiyi_alert_maintenance":    resourceAlertMaintenance(),
+			"rizhiyi_alert_template":       resourceAlertTemplate(),
+			"rizhiyi_parser_rule":          resourceParserRule(),
+			"rizhiyi_account":              resourceAccount(),
+		},
+		DataSourcesMap: map[string]*schema.Resource{
+			"rizhiyi_alert_preview":    dataSourceAlertPreview(),
+			"rizhiyi_alert_history":    dataSourceAlertHistory(),
+			"rizhiyi_dashboard_export": dataSourceDashboardExport(),
+			"rizhiyi_dashboard_render": dataSourceDashboardRender(),
+			"rizhiyi_index_stats":      dataSourceIndexStats(),
+			"rizhiyi_parser_rule_test": dataSourceParserRuleTest(),
 		},
 		ConfigureFunc: providerConfigure,
 	}
@@ -37,5 +60,7 @@ func Provider() *schema.Provider {
 func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 	host := d.Get("host").(string)
 	token := d.Get("token").(string)
-	return yottaweb.NewClient(host, token), nil
+	client := yottaweb.NewClient(host, token)
+	client.RenderEndpoint = d.Get("render_endpoint").(string)
+	return client, nil
 }
@@ -3,6 +3,7 @@ package provider
 import (
 	"encoding/json"
 	"io"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 	"strconv"
 	"net/url"
@@ -18,6 +19,20 @@ func resourceAlert() *schema.Resource {
 		Update: resourceAlertUpdate,
 		Delete: resourceAlertDelete,
 
+		CustomizeDiff: customdiff.Sequence(
+			resourceAlertTemplateDiff,
+			resourceAlertSavedSearchDiff,
+			customdiff.ComputedIf("executor_id", func(d *schema.ResourceDiff, m interface{}) bool {
+				return d.HasChange("executor_name")
+			}),
+			customdiff.ComputedIf("app_id", func(d *schema.ResourceDiff, m interface{}) bool {
+				return d.HasChange("app_name")
+			}),
+			customdiff.ComputedIf("resource_group_ids", func(d *schema.ResourceDiff, m interface{}) bool {
+				return d.HasChange("resource_groups")
+			}),
+		),
+
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:        schema.TypeString,
@@ -30,19 +45,56 @@ func resourceAlert() *schema.Resource {
 				Description: "The monitoring types for the new Alert resource are as follows: 0.Event Count Monitoring, 1.Field Statistics Monitoring, 2.Continuous Statistics Monitoring, 3.Baseline Comparison Monitoring, 4.SPL Statistics Monitoring. (default value 0)",
 			},
 			"query": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "Search content for the alert resource.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"template_id", "saved_search_id"},
+				AtLeastOneOf:  []string{"query", "template_id", "saved_search_id"},
+				Description:   "Search content for the alert resource. Rendered from the template when template_id is set, or taken from the saved search when saved_search_id is set.",
+			},
+			"saved_search_id": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"template_id"},
+				Description:   "ID of a saved search (for example rizhiyi_saved_search.x.id) whose query is used for the alert. Plans compare the alert with the saved search as it is on the platform; set saved_search_revision to also pick up a query changed in the same apply.",
+			},
+			"saved_search_revision": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				RequiredWith: []string{"saved_search_id"},
+				Description:  "Revision of the saved search, for example rizhiyi_saved_search.x.revision. When it changes the query is read again at apply time.",
 			},
 			"check_condition": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"template_id"},
+				AtLeastOneOf:  []string{"check_condition", "template_id"},
+				Description:   "Monitoring trigger conditions for the Alert resource. Rendered from the template when template_id is set.",
+			},
+			"template_id": {
 				Type:        schema.TypeString,
-				Required:    true,
-				Description: "Monitoring trigger conditions for the Alert resource.",
+				Optional:    true,
+				Description: "The body attribute of a rizhiyi_alert_template (for example rizhiyi_alert_template.x.body) whose query and check_condition are rendered for this alert.",
+			},
+			"template_params": {
+				Type:         schema.TypeMap,
+				Elem:         &schema.Schema{Type: schema.TypeString},
+				Optional:     true,
+				RequiredWith: []string{"template_id"},
+				Description:  "Values substituted for the {{param}} placeholders of the template.",
 			},
 			"executor_id": {
-				Type:        schema.TypeInt,
-				Required:    true,
-				Description: "User ID for executing the new Alert resource.（default value 0）",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"executor_id", "executor_name"},
+				Description:  "User ID for executing the new Alert resource.（default value 0）",
+			},
+			"executor_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Account name of the user executing the Alert resource, resolved to executor_id at apply time.",
 			},
 			"description": {
 				Type:        schema.TypeString,
@@ -52,7 +104,7 @@ func resourceAlert() *schema.Resource {
 			"enabled": {
 				Type:        schema.TypeBool,
 				Optional:    true,
-				Description: "The field to enable monitoring for the Alert resource. (default value false)",
+				Description: "The field to enable monitoring for the Alert resource. A rizhiyi_alert_maintenance window disables its alerts while open; add enabled to lifecycle.ignore_changes of alerts it covers. (default value false)",
 			},
 			"crontab": {
 				Type:        schema.TypeString,
@@ -173,17 +225,40 @@ func resourceAlert() *schema.Resource {
 				Description: "Whether the Alert resource uses monitoring reply prompts.(default value false)",
 			},
 			"app_id": {
-				Type:        schema.TypeInt,
-				Optional:    true,
+				Type:          schema.TypeInt,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"app_name"},
+			},
+			"app_name": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"app_id"},
+				Description:   "Name of the app the Alert resource belongs to, resolved to app_id at apply time.",
 			},
 			"timezone": {
 				Type:     schema.TypeString,
 				Optional: true,
 			},
 			"rt_names": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Description: "Resource group name to which the Alert resource belongs, for example: default_Alert, test.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"resource_groups"},
+				Description:   "Resource group name to which the Alert resource belongs, for example: default_Alert, test.",
+			},
+			"resource_groups": {
+				Type:          schema.TypeSet,
+				Elem:          &schema.Schema{Type: schema.TypeString},
+				Optional:      true,
+				ConflictsWith: []string{"rt_names"},
+				Description:   "Names of the resource groups the Alert resource belongs to, checked against the platform at apply time.",
+			},
+			"resource_group_ids": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Computed:    true,
+				Description: "IDs the resource_groups names resolved to.",
 			},
 		},
 	}
@@ -191,6 +266,9 @@ func resourceAlert() *schema.Resource {
 
 func resourceAlertCreate(d *schema.ResourceData, m interface{}) error {
 	c := m.(*yottaweb.Client)
+	if err := resolveAlertReferences(c, d); err != nil {
+		return err
+	}
 	name := d.Get("name").(string)
 	category := d.Get("category").(int)
 	query := d.Get("query").(string)
@@ -477,9 +555,117 @@ func resourceAlertRead(d *schema.ResourceData, m interface{}) error {
 	d.Set("timezone", data["timezone"])
 	d.Set("rt_names", data["rt_names"])
 
+	// 按名称引用时回读当前名称，便于发现平台侧的重命名
+	if _, ok := d.GetOk("executor_name"); ok {
+		if v, ok := toInt(data["executor_id"]); ok {
+			if obj, err := c.GetResourceById(strconv.Itoa(v), "v3", "accounts"); err == nil {
+				d.Set("executor_name", obj["name"])
+			}
+		}
+	}
+	if _, ok := d.GetOk("app_name"); ok {
+		if v, ok := toInt(data["app_id"]); ok && v > 0 {
+			if obj, err := c.GetResourceById(strconv.Itoa(v), "v3", "apps"); err == nil {
+				d.Set("app_name", obj["name"])
+			}
+		}
+	}
+	if _, ok := d.GetOk("resource_groups"); ok {
+		groups := splitIDs(data["rt_names"])
+		d.Set("resource_groups", groups)
+		ids, err := resolveResourceGroupIDs(c, groups)
+		if err != nil {
+			return err
+		}
+		d.Set("resource_group_ids", ids)
+	}
+
 	return nil
 }
 
+// resourceAlertTemplateDiff renders template_id and template_params into query
+// and check_condition at plan time, so template changes show up on every instance.
+func resourceAlertTemplateDiff(d *schema.ResourceDiff, m interface{}) error {
+	if _, ok := d.GetOk("template_id"); !ok {
+		return nil
+	}
+	if !d.NewValueKnown("template_id") || !d.NewValueKnown("template_params") {
+		d.SetNewComputed("query")
+		return d.SetNewComputed("check_condition")
+	}
+	query, checkCondition, err := renderAlertTemplate(d.Get("template_id").(string), d.Get("template_params").(map[string]interface{}))
+	if err != nil {
+		return err
+	}
+	if err := d.SetNew("query", query); err != nil {
+		return err
+	}
+	return d.SetNew("check_condition", checkCondition)
+}
+
+// resourceAlertSavedSearchDiff plans the query of an alert that refers to a
+// saved search. The query is left to apply when the saved search it refers to
+// may change in the same run.
+func resourceAlertSavedSearchDiff(d *schema.ResourceDiff, m interface{}) error {
+	v, ok := d.GetOk("saved_search_id")
+	if !ok {
+		return nil
+	}
+	// 已保存搜索可能在同一次 apply 中变更，此时在 apply 阶段重新读取
+	if !d.NewValueKnown("saved_search_id") || !d.NewValueKnown("saved_search_revision") ||
+		d.HasChange("saved_search_id") || d.HasChange("saved_search_revision") {
+		return d.SetNewComputed("query")
+	}
+	query, err := savedSearchQuery(m.(*yottaweb.Client), v.(string))
+	if err != nil {
+		return err
+	}
+	if old, _ := d.GetChange("query"); old.(string) == query {
+		return nil
+	}
+	return d.SetNew("query", query)
+}
+
+func renderAlertTemplate(body string, rawParams map[string]interface{}) (string, string, error) {
+	tpl, err := decodeAlertTemplate(body)
+	if err != nil {
+		return "", "", err
+	}
+	params := make(map[string]string, len(rawParams))
+	for k, v := range rawParams {
+		params[k] = v.(string)
+	}
+	return tpl.render(params)
+}
+
+// resolveAlertReferences turns template_id, saved_search_id, executor_name,
+// app_name and resource_groups into the fields sent to the platform.
+func resolveAlertReferences(c *yottaweb.Client, d *schema.ResourceData) error {
+	if v, ok := d.GetOk("template_id"); ok {
+		query, checkCondition, err := renderAlertTemplate(v.(string), d.Get("template_params").(map[string]interface{}))
+		if err != nil {
+			return err
+		}
+		d.Set("query", query)
+		d.Set("check_condition", checkCondition)
+	}
+	if v, ok := d.GetOk("saved_search_id"); ok {
+		query, err := savedSearchQuery(c, v.(string))
+		if err != nil {
+			return err
+		}
+		d.Set("query", query)
+	}
+	if v, ok := d.GetOk("executor_name"); ok {
+		id, err := resolveIDByName(c, v.(string), "executor account", "v3", "accounts")
+		if err != nil {
+			return err
+		}
+		d.Set("executor_id", id)
+	}
+	return resolveOwnerReferences(c, d)
+}
+
 // 精确通过 name 查询 alert 的 ID（使用 name 过滤器提高命中率）
 func getAlertIdByName(c *yottaweb.Client, name string) (string, error) {
 	params := url.Values{}
@@ -557,6 +743,9 @@ func getAlertIdByName(c *yottaweb.Client, name string) (string, error) {
 
 func resourceAlertUpdate(d *schema.ResourceData, m interface{}) error {
 	c := m.(*yottaweb.Client)
+	if err := resolveAlertReferences(c, d); err != nil {
+		return err
+	}
 	name := d.Get("name").(string)
 	id := d.Id()
 	category := d.Get("category").(int)
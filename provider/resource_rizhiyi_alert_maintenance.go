@@ -0,0 +1,398 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"terraform-provider-rizhiyi/yottaweb"
+)
+
+// maintenanceTimeLayout is the wall clock format of fixed maintenance windows,
+// interpreted in the window's timezone.
+const maintenanceTimeLayout = "2006-01-02 15:04:05"
+
+// Rizhiyi has no maintenance window API and does not run schedules for the
+// provider, so a window is a switch applied by Terraform: while it is open,
+// apply disables the target alerts, and once it closes, apply enables them again.
+
+func resourceAlertMaintenance() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceAlertMaintenanceCreate,
+		Read:   resourceAlertMaintenanceRead,
+		Update: resourceAlertMaintenanceUpdate,
+		Delete: resourceAlertMaintenanceDelete,
+
+		CustomizeDiff: resourceAlertMaintenanceCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the alert maintenance window.",
+			},
+			"description": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Description of the alert maintenance window.",
+			},
+			"enabled": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Whether the maintenance window is in effect. (default value true)",
+			},
+			"start_time": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateMaintenanceTime,
+				RequiredWith: []string{"end_time"},
+				ExactlyOneOf: []string{"start_time", "crontab"},
+				Description:  "Start of a one-off window, for example: 2024-01-01 22:00:00.",
+			},
+			"end_time": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateMaintenanceTime,
+				RequiredWith: []string{"start_time"},
+				Description:  "End of a one-off window, for example: 2024-01-02 02:00:00.",
+			},
+			"crontab": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.All(validateCrontab, validateCrontabSchedule),
+				RequiredWith: []string{"duration"},
+				ExactlyOneOf: []string{"start_time", "crontab"},
+				Description:  "Crontab statement that opens a recurring window, using the same format as rizhiyi_alert.crontab, for example: 0 0 2 ? * SUN. L, W and # are not supported.",
+			},
+			"duration": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntBetween(1, 31*24*3600),
+				RequiredWith: []string{"crontab"},
+				Description:  "Length (in seconds) of each recurring window opened by crontab, at most 31 days.",
+			},
+			"timezone": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "Asia/Shanghai",
+				ValidateFunc: validateTimezone,
+				Description:  "Timezone used to interpret start_time, end_time and crontab. (default value Asia/Shanghai)",
+			},
+			"alert_ids": &schema.Schema{
+				Type:         schema.TypeSet,
+				Elem:         &schema.Schema{Type: schema.TypeInt},
+				Optional:     true,
+				AtLeastOneOf: []string{"alert_ids", "name_pattern"},
+				Description:  "IDs of the alerts muted during the window.",
+			},
+			"name_pattern": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+				Description:  "Regular expression; alerts whose name matches are muted during the window.",
+			},
+			"active": &schema.Schema{
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the window was open when the resource was last read. The window only takes effect when Terraform applies: run apply (for example from a scheduler) when it opens and after it closes.",
+			},
+			"muted_alert_ids": &schema.Schema{
+				Type:        schema.TypeSet,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Computed:    true,
+				Description: "IDs of the alerts the window disabled, enabled again when it closes. Alerts that were already disabled are left alone.",
+			},
+		},
+	}
+}
+
+func validateMaintenanceTime(i interface{}, k string) (warnings []string, errors []error) {
+	v, ok := i.(string)
+	if !ok {
+		errors = append(errors, fmt.Errorf("expected type of %s to be string", k))
+		return
+	}
+	if _, err := time.Parse(maintenanceTimeLayout, v); err != nil {
+		errors = append(errors, fmt.Errorf("expected %s to be in the format %q, got %q", k, maintenanceTimeLayout, v))
+	}
+	return
+}
+
+func validateTimezone(i interface{}, k string) (warnings []string, errors []error) {
+	v, ok := i.(string)
+	if !ok {
+		errors = append(errors, fmt.Errorf("expected type of %s to be string", k))
+		return
+	}
+	if _, err := time.LoadLocation(v); err != nil {
+		errors = append(errors, fmt.Errorf("expected %s to be a valid timezone, got %q", k, v))
+	}
+	return
+}
+
+// alertMaintenanceConfig is implemented by both *schema.ResourceData and
+// *schema.ResourceDiff.
+type alertMaintenanceConfig interface {
+	Get(key string) interface{}
+}
+
+// alertMaintenanceWindow reports whether the window is open at now and, if so,
+// when it closes.
+func alertMaintenanceWindow(d alertMaintenanceConfig, now time.Time) (bool, time.Time, error) {
+	if !d.Get("enabled").(bool) {
+		return false, time.Time{}, nil
+	}
+	loc, err := time.LoadLocation(d.Get("timezone").(string))
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	if start := d.Get("start_time").(string); start != "" {
+		startTime, err := time.ParseInLocation(maintenanceTimeLayout, start, loc)
+		if err != nil {
+			return false, time.Time{}, err
+		}
+		endTime, err := time.ParseInLocation(maintenanceTimeLayout, d.Get("end_time").(string), loc)
+		if err != nil {
+			return false, time.Time{}, err
+		}
+		return !now.Before(startTime) && now.Before(endTime), endTime, nil
+	}
+
+	schedule, err := parseCrontab(d.Get("crontab").(string))
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	duration := time.Duration(d.Get("duration").(int)) * time.Second
+	opened, ok := schedule.lastFiring(now.In(loc), duration)
+	if !ok {
+		return false, time.Time{}, nil
+	}
+	return true, opened.Add(duration), nil
+}
+
+// alertMaintenanceTargets returns the IDs of the alerts the window covers.
+func alertMaintenanceTargets(c *yottaweb.Client, d alertMaintenanceConfig) ([]string, error) {
+	targets := map[string]bool{}
+	for _, v := range d.Get("alert_ids").(*schema.Set).List() {
+		targets[strconv.Itoa(v.(int))] = true
+	}
+	if pattern := d.Get("name_pattern").(string); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		alerts, err := listResources(c, "v3", "alerts")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list alerts: %s", err)
+		}
+		for _, alert := range alerts {
+			if re.MatchString(stringValue(alert["name"])) {
+				targets[idString(alert["id"])] = true
+			}
+		}
+	}
+	ids := make([]string, 0, len(targets))
+	for id := range targets {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// resourceAlertMaintenanceCustomizeDiff plans disabling or enabling alerts when
+// the window has opened or closed, or its targets changed, since the last apply.
+func resourceAlertMaintenanceCustomizeDiff(d *schema.ResourceDiff, m interface{}) error {
+	for _, k := range []string{"enabled", "start_time", "end_time", "crontab", "duration", "timezone", "alert_ids", "name_pattern"} {
+		if !d.NewValueKnown(k) {
+			if err := d.SetNewComputed("active"); err != nil {
+				return err
+			}
+			return d.SetNewComputed("muted_alert_ids")
+		}
+	}
+
+	open, _, err := alertMaintenanceWindow(d, time.Now())
+	if err != nil {
+		return err
+	}
+	targets := []string{}
+	if open {
+		if targets, err = alertMaintenanceTargets(m.(*yottaweb.Client), d); err != nil {
+			return err
+		}
+	}
+	muted := alertMaintenanceMuted(d.Get("muted_alert_ids"))
+	if d.Get("active").(bool) == open && (!open || alertMaintenanceCovers(muted, targets)) && (open || len(muted) == 0) {
+		return nil
+	}
+	if err := d.SetNew("active", open); err != nil {
+		return err
+	}
+	return d.SetNewComputed("muted_alert_ids")
+}
+
+func alertMaintenanceMuted(v interface{}) map[string]bool {
+	muted := map[string]bool{}
+	if set, ok := v.(*schema.Set); ok {
+		for _, id := range set.List() {
+			muted[id.(string)] = true
+		}
+	}
+	return muted
+}
+
+// alertMaintenanceCovers reports whether every target was disabled by the window
+// and no other alert is.
+func alertMaintenanceCovers(muted map[string]bool, targets []string) bool {
+	if len(muted) != len(targets) {
+		return false
+	}
+	for _, id := range targets {
+		if !muted[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// setAlertEnabled changes only the enabled flag of an alert.
+func setAlertEnabled(c *yottaweb.Client, id string, enabled bool) error {
+	endpoint := c.BuildRizhiyiURL(nil, "v3", "alerts", id)
+	resp, err := c.Patch(endpoint, map[string]interface{}{"enabled": enabled})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return nil
+}
+
+// readAlertEnabled returns whether an alert is enabled, and false for ok when it
+// no longer exists.
+func readAlertEnabled(c *yottaweb.Client, id string) (enabled bool, ok bool, err error) {
+	alert, err := c.GetResourceById(id, "v3", "alerts")
+	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			return false, false, nil
+		}
+		return false, false, fmt.Errorf("failed to read alert %s: %s", id, err)
+	}
+	enabled, _ = alert["enabled"].(bool)
+	return enabled, true, nil
+}
+
+// applyAlertMaintenance disables the enabled targets of an open window and
+// enables the alerts it disabled that it no longer covers. Progress is kept in
+// state even when a call fails.
+func applyAlertMaintenance(d *schema.ResourceData, c *yottaweb.Client) error {
+	open, _, err := alertMaintenanceWindow(d, time.Now())
+	if err != nil {
+		return err
+	}
+	targets := []string{}
+	if open {
+		if targets, err = alertMaintenanceTargets(c, d); err != nil {
+			return err
+		}
+	}
+
+	old, _ := d.GetChange("muted_alert_ids")
+	muted := alertMaintenanceMuted(old)
+	defer func() {
+		ids := make([]interface{}, 0, len(muted))
+		for id := range muted {
+			ids = append(ids, id)
+		}
+		d.Set("muted_alert_ids", schema.NewSet(schema.HashString, ids))
+		d.Set("active", open)
+	}()
+
+	keep := map[string]bool{}
+	for _, id := range targets {
+		keep[id] = true
+	}
+	for id := range muted {
+		if keep[id] {
+			continue
+		}
+		if err := setAlertEnabled(c, id, true); err != nil && !strings.Contains(err.Error(), "404") {
+			return fmt.Errorf("failed to enable alert %s: %s", id, err)
+		}
+		delete(muted, id)
+	}
+	for _, id := range targets {
+		if muted[id] {
+			continue
+		}
+		enabled, ok, err := readAlertEnabled(c, id)
+		if err != nil {
+			return err
+		}
+		if !ok || !enabled {
+			// 已停用的告警不由维护窗口接管，结束时也不会被启用
+			continue
+		}
+		if err := setAlertEnabled(c, id, false); err != nil {
+			return fmt.Errorf("failed to disable alert %s: %s", id, err)
+		}
+		muted[id] = true
+	}
+	return nil
+}
+
+func resourceAlertMaintenanceCreate(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	d.SetId(randomID("alert-maintenance-"))
+	if err := applyAlertMaintenance(d, c); err != nil {
+		return err
+	}
+	return resourceAlertMaintenanceRead(d, m)
+}
+
+// resourceAlertMaintenanceRead refreshes whether the window is open and drops
+// alerts that were deleted or enabled again outside Terraform, so the next plan
+// disables them again while the window is open.
+func resourceAlertMaintenanceRead(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	open, _, err := alertMaintenanceWindow(d, time.Now())
+	if err != nil {
+		return err
+	}
+	ids := make([]interface{}, 0)
+	for id := range alertMaintenanceMuted(d.Get("muted_alert_ids")) {
+		enabled, ok, err := readAlertEnabled(c, id)
+		if err != nil {
+			return err
+		}
+		if ok && !enabled {
+			ids = append(ids, id)
+		}
+	}
+	d.Set("muted_alert_ids", schema.NewSet(schema.HashString, ids))
+	d.Set("active", open)
+	return nil
+}
+
+func resourceAlertMaintenanceUpdate(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	if err := applyAlertMaintenance(d, c); err != nil {
+		return err
+	}
+	return resourceAlertMaintenanceRead(d, m)
+}
+
+func resourceAlertMaintenanceDelete(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	for id := range alertMaintenanceMuted(d.Get("muted_alert_ids")) {
+		if err := setAlertEnabled(c, id, true); err != nil && !strings.Contains(err.Error(), "404") {
+			return fmt.Errorf("failed to enable alert %s: %s", id, err)
+		}
+	}
+	d.SetId("")
+	return nil
+}
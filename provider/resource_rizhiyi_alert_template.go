@@ -0,0 +1,199 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// alertTemplatePlaceholder matches {{param}} placeholders. ${...} is avoided as
+// it clashes with Terraform interpolation.
+var alertTemplatePlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// alertTemplate is the body of a rizhiyi_alert_template. Rizhiyi has no alert
+// template API, so the body is passed to alerts as template_id and lives in
+// state only.
+type alertTemplate struct {
+	Name           string            `json:"name"`
+	Query          string            `json:"query"`
+	CheckCondition string            `json:"check_condition"`
+	Defaults       map[string]string `json:"defaults,omitempty"`
+}
+
+func resourceAlertTemplate() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceAlertTemplateCreate,
+		Read:   resourceAlertTemplateRead,
+		Update: resourceAlertTemplateUpdate,
+		Delete: resourceAlertTemplateDelete,
+
+		CustomizeDiff: resourceAlertTemplateCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the alert template.",
+			},
+			"description": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Description of the alert template.",
+			},
+			"query": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Search content of the alert, with {{param}} placeholders, for example: appname:{{appname}} AND ERROR.",
+			},
+			"check_condition": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Monitoring trigger conditions of the alert, with {{param}} placeholders.",
+			},
+			"defaults": &schema.Schema{
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Optional:    true,
+				Description: "Default values of placeholders that rizhiyi_alert.template_params may omit.",
+			},
+			"parameters": &schema.Schema{
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Computed:    true,
+				Description: "Placeholder names used by query and check_condition.",
+			},
+			"body": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "JSON body of the template, passed to rizhiyi_alert.template_id so instances are rendered at plan time.",
+			},
+			"content_hash": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Hash of body, changing whenever query, check_condition or defaults change.",
+			},
+		},
+	}
+}
+
+func alertTemplateFromConfig(get func(string) interface{}) alertTemplate {
+	defaults := make(map[string]string)
+	for k, v := range get("defaults").(map[string]interface{}) {
+		defaults[k] = v.(string)
+	}
+	return alertTemplate{
+		Name:           get("name").(string),
+		Query:          get("query").(string),
+		CheckCondition: get("check_condition").(string),
+		Defaults:       defaults,
+	}
+}
+
+func resourceAlertTemplateCustomizeDiff(d *schema.ResourceDiff, m interface{}) error {
+	if !d.NewValueKnown("name") || !d.NewValueKnown("query") || !d.NewValueKnown("check_condition") || !d.NewValueKnown("defaults") {
+		for _, k := range []string{"parameters", "body", "content_hash"} {
+			if err := d.SetNewComputed(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	tpl := alertTemplateFromConfig(d.Get)
+	if err := d.SetNew("parameters", tpl.parameters()); err != nil {
+		return err
+	}
+	if err := d.SetNew("body", tpl.encode()); err != nil {
+		return err
+	}
+	return d.SetNew("content_hash", tpl.hash())
+}
+
+func resourceAlertTemplateCreate(d *schema.ResourceData, m interface{}) error {
+	d.SetId(d.Get("name").(string))
+	return resourceAlertTemplateUpdate(d, m)
+}
+
+func resourceAlertTemplateRead(d *schema.ResourceData, m interface{}) error {
+	return nil
+}
+
+func resourceAlertTemplateUpdate(d *schema.ResourceData, m interface{}) error {
+	tpl := alertTemplateFromConfig(d.Get)
+	d.Set("parameters", tpl.parameters())
+	d.Set("body", tpl.encode())
+	d.Set("content_hash", tpl.hash())
+	return nil
+}
+
+func resourceAlertTemplateDelete(d *schema.ResourceData, m interface{}) error {
+	d.SetId("")
+	return nil
+}
+
+func (t alertTemplate) encode() string {
+	b, _ := json.Marshal(t)
+	return string(b)
+}
+
+func (t alertTemplate) hash() string {
+	sum := sha256.Sum256([]byte(t.encode()))
+	return hex.EncodeToString(sum[:])
+}
+
+// decodeAlertTemplate reads the body of a template.
+func decodeAlertTemplate(body string) (alertTemplate, error) {
+	var tpl alertTemplate
+	if err := json.Unmarshal([]byte(body), &tpl); err != nil {
+		return tpl, fmt.Errorf("template_id must be the body attribute of a rizhiyi_alert_template: %s", err)
+	}
+	return tpl, nil
+}
+
+func (t alertTemplate) parameters() []string {
+	seen := make(map[string]bool)
+	params := make([]string, 0)
+	for _, s := range []string{t.Query, t.CheckCondition} {
+		for _, match := range alertTemplatePlaceholder.FindAllStringSubmatch(s, -1) {
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				params = append(params, match[1])
+			}
+		}
+	}
+	sort.Strings(params)
+	return params
+}
+
+// render substitutes params (falling back to the template defaults) into the
+// template and returns the final query and check_condition.
+func (t alertTemplate) render(params map[string]string) (string, string, error) {
+	missing := make([]string, 0)
+	for _, p := range t.parameters() {
+		if _, ok := params[p]; ok {
+			continue
+		}
+		if _, ok := t.Defaults[p]; !ok {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) > 0 {
+		return "", "", fmt.Errorf("alert template %q is missing template_params: %s", t.Name, strings.Join(missing, ", "))
+	}
+	replace := func(s string) string {
+		return alertTemplatePlaceholder.ReplaceAllStringFunc(s, func(match string) string {
+			name := alertTemplatePlaceholder.FindStringSubmatch(match)[1]
+			if v, ok := params[name]; ok {
+				return v
+			}
+			return t.Defaults[name]
+		})
+	}
+	return replace(t.Query), replace(t.CheckCondition), nil
+}
@@ -0,0 +1,222 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"terraform-provider-rizhiyi/yottaweb"
+)
+
+// Subject types of a resource permission grant.
+const (
+	permissionSubjectRole    = "role"
+	permissionSubjectAccount = "account"
+)
+
+func resourceDashboardPermission() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceDashboardPermissionCreate,
+		Read:   resourceDashboardPermissionRead,
+		Update: resourceDashboardPermissionUpdate,
+		Delete: resourceDashboardPermissionDelete,
+
+		CustomizeDiff: resourceDashboardPermissionCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"dashboard_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the dashboard whose permissions are managed. The resource owns every grant of the dashboard; grants not listed here are removed.",
+			},
+			"grant": &schema.Schema{
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Role or account allowed to access the dashboard.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"role_id": &schema.Schema{
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Description: "ID of the role granted access. Exactly one of role_id and account_id must be set.",
+						},
+						"account_id": &schema.Schema{
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Description: "ID of the account granted access. Exactly one of role_id and account_id must be set.",
+						},
+						"access": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "read",
+							ValidateFunc: validation.StringInSlice([]string{"read", "write"}, false),
+							Description:  "Access level: read (view) or write (view and edit). (default value read)",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourceDashboardPermissionCustomizeDiff(d *schema.ResourceDiff, m interface{}) error {
+	if !d.NewValueKnown("grant") {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for _, g := range d.Get("grant").(*schema.Set).List() {
+		grant := g.(map[string]interface{})
+		roleID, accountID := grant["role_id"].(int), grant["account_id"].(int)
+		if (roleID > 0) == (accountID > 0) {
+			return fmt.Errorf("each grant must set exactly one of role_id and account_id")
+		}
+		key := fmt.Sprintf("role:%d", roleID)
+		if accountID > 0 {
+			key = fmt.Sprintf("account:%d", accountID)
+		}
+		if seen[key] {
+			return fmt.Errorf("duplicate grant for %s", key)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// expandDashboardGrants converts grant blocks to the permission list of the API.
+func expandDashboardGrants(grants []interface{}) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(grants))
+	for _, g := range grants {
+		grant := g.(map[string]interface{})
+		item := map[string]interface{}{
+			"access": grant["access"].(string),
+		}
+		if v := grant["account_id"].(int); v > 0 {
+			item["subject_type"] = permissionSubjectAccount
+			item["subject_id"] = v
+		} else {
+			item["subject_type"] = permissionSubjectRole
+			item["subject_id"] = grant["role_id"].(int)
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+// flattenDashboardGrants converts the permission list of the API to grant blocks.
+func flattenDashboardGrants(permissions []map[string]interface{}) []interface{} {
+	out := make([]interface{}, 0, len(permissions))
+	for _, p := range permissions {
+		id, ok := toInt(p["subject_id"])
+		if !ok {
+			continue
+		}
+		grant := map[string]interface{}{
+			"role_id":    0,
+			"account_id": 0,
+			"access":     "read",
+		}
+		if v := stringValue(p["access"]); v != "" {
+			grant["access"] = v
+		}
+		switch stringValue(p["subject_type"]) {
+		case permissionSubjectAccount:
+			grant["account_id"] = id
+		case permissionSubjectRole:
+			grant["role_id"] = id
+		default:
+			continue
+		}
+		out = append(out, grant)
+	}
+	return out
+}
+
+// getDashboardPermissions lists the grants of a dashboard, returning nil when the
+// dashboard no longer exists.
+func getDashboardPermissions(c *yottaweb.Client, dashboardID string) ([]map[string]interface{}, error) {
+	permissions, err := listResources(c, "..", "v3", "dashboards", dashboardID, "permissions")
+	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read permissions of dashboard %s: %s", dashboardID, err)
+	}
+	return permissions, nil
+}
+
+// setDashboardPermissions replaces every grant of a dashboard.
+func setDashboardPermissions(c *yottaweb.Client, dashboardID string, permissions []map[string]interface{}) error {
+	endpoint := c.BuildRizhiyiURL(nil, "..", "v3", "dashboards", dashboardID, "permissions")
+	resp, err := c.Put(endpoint, map[string]interface{}{
+		"list": permissions,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set permissions of dashboard %s: %s", dashboardID, err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	var result map[string]interface{}
+	if err := json.Unmarshal(bodyBytes, &result); err == nil {
+		if res, ok := result["result"].(bool); ok && !res {
+			return fmt.Errorf("failed to set permissions of dashboard %s: %s", dashboardID, string(bodyBytes))
+		}
+	}
+	return nil
+}
+
+func resourceDashboardPermissionCreate(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	dashboardID := d.Get("dashboard_id").(string)
+
+	if err := setDashboardPermissions(c, dashboardID, expandDashboardGrants(d.Get("grant").(*schema.Set).List())); err != nil {
+		return err
+	}
+	d.SetId(dashboardID)
+	return resourceDashboardPermissionRead(d, m)
+}
+
+func resourceDashboardPermissionRead(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+
+	permissions, err := getDashboardPermissions(c, d.Id())
+	if err != nil {
+		return err
+	}
+	if permissions == nil {
+		d.SetId("")
+		return nil
+	}
+	d.Set("dashboard_id", d.Id())
+	// 平台上被移除的授权在这里消失，从而作为 drift 出现在 plan 中
+	return d.Set("grant", flattenDashboardGrants(permissions))
+}
+
+func resourceDashboardPermissionUpdate(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+
+	if d.HasChange("grant") {
+		if err := setDashboardPermissions(c, d.Id(), expandDashboardGrants(d.Get("grant").(*schema.Set).List())); err != nil {
+			return err
+		}
+	}
+	return resourceDashboardPermissionRead(d, m)
+}
+
+func resourceDashboardPermissionDelete(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+
+	if err := setDashboardPermissions(c, d.Id(), []map[string]interface{}{}); err != nil {
+		if strings.Contains(err.Error(), "404") {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+	d.SetId("")
+	return nil
+}
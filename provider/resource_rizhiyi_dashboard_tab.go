@@ -0,0 +1,393 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/mutexkv"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"terraform-provider-rizhiyi/yottaweb"
+)
+
+// dashboardMutexKV serialises tab changes per dashboard, since tab order is
+// stored on the dashboard itself.
+var dashboardMutexKV = mutexkv.NewMutexKV()
+
+func resourceDashboardTab() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceDashboardTabCreate,
+		Read:   resourceDashboardTabRead,
+		Update: resourceDashboardTabUpdate,
+		Delete: resourceDashboardTabDelete,
+
+		Importer: &schema.ResourceImporter{
+			State: resourceDashboardTabImport,
+		},
+
+		CustomizeDiff: resourceDashboardTabCustomizeDiff,
+
+		Schema: dashboardTabSchema(map[string]*schema.Schema{
+			"dashboard_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the dashboard the tab belongs to. The dashboard should set manage_tabs = false. Existing tabs are imported with the ID dashboard_id/tab_id.",
+			},
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the tab.",
+			},
+			"content": &schema.Schema{
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				DiffSuppressFunc: suppressEquivalentJSON,
+				Description:      "Content of the tab (JSON string). Leave unset when the tab is described with refresh, filter and widget blocks.",
+			},
+			"position": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Zero-based position of the tab in the dashboard, at most the number of other tabs. Defaults to after the existing tabs.",
+			},
+			"uuid": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "UUID of the tab.",
+			},
+			"creator_id": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Creator ID of the tab.",
+			},
+		}),
+	}
+}
+
+func resourceDashboardTabCustomizeDiff(d *schema.ResourceDiff, m interface{}) error {
+	tab := make(map[string]interface{})
+	for _, k := range []string{"name", "refresh", "filter", "widget"} {
+		tab[k] = d.Get(k)
+	}
+	if !tabHasTypedContent(tab) {
+		return nil
+	}
+	return checkDashboardTabReferences(tab)
+}
+
+// dashboardTabFromResourceData returns the tab attributes in the shape used by the
+// tabs blocks of rizhiyi_dashboard, so both share the content serialization.
+func dashboardTabFromResourceData(d *schema.ResourceData) map[string]interface{} {
+	tab := make(map[string]interface{})
+	for _, k := range []string{"name", "content", "refresh", "filter", "widget"} {
+		tab[k] = d.Get(k)
+	}
+	return tab
+}
+
+func resourceDashboardTabCreate(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	dashboardID := d.Get("dashboard_id").(string)
+	name := d.Get("name").(string)
+	content, err := dashboardTabContent(dashboardTabFromResourceData(d))
+	if err != nil {
+		return err
+	}
+
+	dashboardMutexKV.Lock(dashboardID)
+	defer dashboardMutexKV.Unlock(dashboardID)
+
+	// 先检查 position，避免创建 tab 后才发现位置越界
+	if v, ok := d.GetOkExists("position"); ok {
+		dashboard, err := getDashboardObject(c, dashboardID)
+		if err != nil {
+			return err
+		}
+		if dashboard == nil {
+			return fmt.Errorf("dashboard %s not found", dashboardID)
+		}
+		if count := len(dashboardTabs(dashboard)); v.(int) > count {
+			return fmt.Errorf("position %d of tab %s is out of range, dashboard %s has %d other tabs", v.(int), name, dashboardID, count)
+		}
+	}
+
+	endpoint := c.BuildRizhiyiURL(nil, "..", "v3", "dashboards", dashboardID, "tabs")
+	resp, err := c.Post(endpoint, map[string]interface{}{
+		"name":    name,
+		"content": content,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create tab %s: %s", name, err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	tabID := idFromCreateResponse(bodyBytes)
+	if tabID == "" {
+		// 响应中没有 id 时，按名称在 dashboard 中查找最新创建的 tab
+		dashboard, err := getDashboardObject(c, dashboardID)
+		if err != nil {
+			return err
+		}
+		for _, tab := range dashboardTabs(dashboard) {
+			if tab["name"] == name {
+				tabID = idString(tab["id"])
+			}
+		}
+	}
+	if tabID == "" {
+		return fmt.Errorf("tab %s created but id not resolvable", name)
+	}
+	d.SetId(tabID)
+
+	if v, ok := d.GetOkExists("position"); ok {
+		if err := moveDashboardTab(c, dashboardID, tabID, v.(int)); err != nil {
+			return err
+		}
+	}
+	return resourceDashboardTabRead(d, m)
+}
+
+func resourceDashboardTabRead(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	dashboardID := d.Get("dashboard_id").(string)
+
+	dashboard, err := getDashboardObject(c, dashboardID)
+	if err != nil {
+		return err
+	}
+	if dashboard == nil {
+		d.SetId("")
+		return nil
+	}
+
+	tabs := orderedDashboardTabs(dashboard)
+	for i, tab := range tabs {
+		if idString(tab["id"]) != d.Id() {
+			continue
+		}
+		content := stringValue(tab["content"])
+		d.Set("name", tab["name"])
+		d.Set("content", content)
+		d.Set("position", i)
+		d.Set("uuid", stringValue(tab["uuid"]))
+		if v, ok := toInt(tab["creator_id"]); ok {
+			d.Set("creator_id", v)
+		}
+		if tabHasTypedContent(dashboardTabFromResourceData(d)) {
+			typed, err := flattenDashboardTabContent(stringValue(tab["name"]), content)
+			if err != nil {
+				return fmt.Errorf("failed to decode content of tab %s: %s", d.Id(), err)
+			}
+			if v, _ := d.Get("refresh").([]interface{}); len(v) == 0 {
+				delete(typed, "refresh")
+			}
+			for k, v := range typed {
+				d.Set(k, v)
+			}
+		}
+		return nil
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// resourceDashboardTabImport imports a tab by dashboard_id/tab_id.
+func resourceDashboardTabImport(d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	parts := strings.SplitN(d.Id(), "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("unexpected ID %q, expected dashboard_id/tab_id", d.Id())
+	}
+	d.Set("dashboard_id", parts[0])
+	d.SetId(parts[1])
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceDashboardTabUpdate(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	dashboardID := d.Get("dashboard_id").(string)
+
+	dashboardMutexKV.Lock(dashboardID)
+	defer dashboardMutexKV.Unlock(dashboardID)
+
+	if d.HasChanges("name", "content", "refresh", "filter", "widget") {
+		content, err := dashboardTabContent(dashboardTabFromResourceData(d))
+		if err != nil {
+			return err
+		}
+		endpoint := c.BuildRizhiyiURL(nil, "..", "v3", "dashboards", dashboardID, "tabs", d.Id())
+		resp, err := c.Put(endpoint, map[string]interface{}{
+			"name":    d.Get("name").(string),
+			"content": content,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update tab %s: %s", d.Id(), err)
+		}
+		resp.Body.Close()
+	}
+
+	if d.HasChange("position") {
+		if err := moveDashboardTab(c, dashboardID, d.Id(), d.Get("position").(int)); err != nil {
+			return err
+		}
+	}
+	return resourceDashboardTabRead(d, m)
+}
+
+func resourceDashboardTabDelete(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	dashboardID := d.Get("dashboard_id").(string)
+
+	dashboardMutexKV.Lock(dashboardID)
+	defer dashboardMutexKV.Unlock(dashboardID)
+
+	endpoint := c.BuildRizhiyiURL(nil, "..", "v3", "dashboards", dashboardID, "tabs", d.Id())
+	resp, err := c.Delete(endpoint)
+	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+	resp.Body.Close()
+	d.SetId("")
+	return nil
+}
+
+// getDashboardObject reads a dashboard, returning nil when it no longer exists.
+func getDashboardObject(c *yottaweb.Client, id string) (map[string]interface{}, error) {
+	endpoint := c.BuildRizhiyiURL(nil, "..", "v3", "dashboards", id)
+	resp, err := c.Get(endpoint)
+	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	var result map[string]interface{}
+	if err := json.Unmarshal(bodyBytes, &result); err != nil {
+		return nil, err
+	}
+	if res, ok := result["result"].(bool); ok && !res {
+		if errObj, ok := result["error"].(map[string]interface{}); ok {
+			return nil, fmt.Errorf("API error reading dashboard %s: code=%v, message=%v", id, errObj["code"], errObj["message"])
+		}
+		return nil, fmt.Errorf("API returned result: false but no error detail for dashboard %s", id)
+	}
+	dashboard, ok := result["object"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected response format for dashboard read: %s", string(bodyBytes))
+	}
+	return dashboard, nil
+}
+
+// dashboardTabs returns the tab objects of a dashboard in server order.
+func dashboardTabs(dashboard map[string]interface{}) []map[string]interface{} {
+	tabs := make([]map[string]interface{}, 0)
+	if list, ok := dashboard["tabs"].([]interface{}); ok {
+		for _, t := range list {
+			if tab, ok := t.(map[string]interface{}); ok {
+				tabs = append(tabs, tab)
+			}
+		}
+	}
+	return tabs
+}
+
+// parseDashboardSequences reads the tab order stored in a dashboard's sequences
+// field, either a JSON array or a comma separated list of tab IDs.
+func parseDashboardSequences(v interface{}) []string {
+	if s, ok := v.(string); ok && strings.HasPrefix(strings.TrimSpace(s), "[") {
+		var arr []interface{}
+		if err := json.Unmarshal([]byte(s), &arr); err == nil {
+			return splitIDs(arr)
+		}
+	}
+	return splitIDs(v)
+}
+
+// orderedDashboardTabs returns the tabs of a dashboard in display order: the
+// order of sequences first, then any tab missing from it in server order.
+func orderedDashboardTabs(dashboard map[string]interface{}) []map[string]interface{} {
+	tabs := dashboardTabs(dashboard)
+	byID := make(map[string]map[string]interface{}, len(tabs))
+	for _, tab := range tabs {
+		byID[idString(tab["id"])] = tab
+	}
+	ordered := make([]map[string]interface{}, 0, len(tabs))
+	seen := make(map[string]bool, len(tabs))
+	for _, id := range parseDashboardSequences(dashboard["sequences"]) {
+		if tab, ok := byID[id]; ok && !seen[id] {
+			ordered = append(ordered, tab)
+			seen[id] = true
+		}
+	}
+	for _, tab := range tabs {
+		if id := idString(tab["id"]); !seen[id] {
+			ordered = append(ordered, tab)
+			seen[id] = true
+		}
+	}
+	return ordered
+}
+
+// moveDashboardTab moves a tab to position and stores the new order in sequences.
+func moveDashboardTab(c *yottaweb.Client, dashboardID, tabID string, position int) error {
+	dashboard, err := getDashboardObject(c, dashboardID)
+	if err != nil {
+		return err
+	}
+	if dashboard == nil {
+		return fmt.Errorf("dashboard %s not found", dashboardID)
+	}
+	order := make([]string, 0)
+	for _, tab := range orderedDashboardTabs(dashboard) {
+		if id := idString(tab["id"]); id != tabID {
+			order = append(order, id)
+		}
+	}
+	if position > len(order) {
+		return fmt.Errorf("position %d of tab %s is out of range, dashboard %s has %d other tabs", position, tabID, dashboardID, len(order))
+	}
+	order = append(order[:position], append([]string{tabID}, order[position:]...)...)
+	return setDashboardSequences(c, dashboardID, dashboard, order)
+}
+
+// setDashboardSequences writes the tab order back to the dashboard.
+func setDashboardSequences(c *yottaweb.Client, dashboardID string, dashboard map[string]interface{}, order []string) error {
+	ids := make([]int, 0, len(order))
+	for _, id := range order {
+		if v, err := strconv.Atoi(id); err == nil {
+			ids = append(ids, v)
+		}
+	}
+	sequences, _ := json.Marshal(ids)
+
+	requestBody := map[string]interface{}{
+		"name":      dashboard["name"],
+		"sequences": string(sequences),
+	}
+	for _, k := range []string{"rt_names", "app_id", "data_user", "export", "default_display", "active_tab"} {
+		if v, ok := dashboard[k]; ok && v != nil {
+			requestBody[k] = v
+		}
+	}
+	endpoint := c.BuildRizhiyiURL(nil, "..", "v3", "dashboards", dashboardID)
+	resp, err := c.Put(endpoint, requestBody)
+	if err != nil {
+		return fmt.Errorf("failed to reorder tabs of dashboard %s: %s", dashboardID, err)
+	}
+	resp.Body.Close()
+	return nil
+}
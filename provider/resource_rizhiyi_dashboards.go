@@ -8,7 +8,9 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/hashicorp/terraform-plugin-sdk/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 	"terraform-provider-rizhiyi/yottaweb"
 )
 
@@ -19,6 +21,11 @@ func resourceDashboards() *schema.Resource {
 		Update: resourceDashboardsUpdate,
 		Delete: resourceDashboardsDelete,
 
+		CustomizeDiff: customdiff.Sequence(
+			resourceDashboardsCustomizeDiff,
+			resourceDashboardsTokenDiff,
+		),
+
 		Schema: map[string]*schema.Schema{
 			"name": &schema.Schema{
 				Type:        schema.TypeString,
@@ -55,7 +62,8 @@ func resourceDashboards() *schema.Resource {
 			"sequences": &schema.Schema{
 				Type:        schema.TypeString,
 				Optional:    true,
-				Description: "Sequences configuration.",
+				Computed:    true,
+				Description: "Sequences configuration: the display order of the tab IDs. Kept from the server when unset, so rizhiyi_dashboard_tab.position can manage it.",
 			},
 			"active_tab": &schema.Schema{
 				Type:        schema.TypeInt,
@@ -68,12 +76,44 @@ func resourceDashboards() *schema.Resource {
 				Default:     false,
 				Description: "Whether Terraform should manage dashboard tabs. If false, tabs are read-only and ignored in diffs.",
 			},
+			"source_file": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"tabs"},
+				Description:   "Path of a Rizhiyi dashboard export (JSON, or tar/tar.gz holding dashboard.json and trends). Its tabs are created on the dashboard and the trends they reference are imported, with trendId rewritten to the new IDs.",
+			},
+			"trend_import_mode": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "reuse",
+				ValidateFunc: validation.StringInSlice([]string{"reuse", "clone"}, false),
+				Description:  "How trends of source_file are imported: reuse (use an existing trend of the same name, else create it) or clone (always create a copy). (default value reuse)",
+			},
+			"source_file_sha256": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "SHA-256 of source_file; a changed file replaces the dashboard.",
+			},
+			"imported_trend_ids": &schema.Schema{
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Computed:    true,
+				Description: "Trend IDs of source_file mapped to the IDs used on this platform.",
+			},
+			"created_trend_ids": &schema.Schema{
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Computed:    true,
+				Description: "Trends created while importing source_file; they are deleted with the dashboard.",
+			},
 			"tabs": &schema.Schema{
 				Type:     schema.TypeList,
 				Optional: true,
 				Computed: true,
 				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
+					Schema: dashboardTabSchema(map[string]*schema.Schema{
 						"id": &schema.Schema{
 							Type:        schema.TypeInt,
 							Computed:    true,
@@ -86,9 +126,10 @@ func resourceDashboards() *schema.Resource {
 						},
 						"content": &schema.Schema{
 							Type:        schema.TypeString,
-							Required:    true,
+							Optional:    true,
+							Computed:    true,
 							DiffSuppressFunc: suppressEquivalentJSON,
-							Description: "Content of the tab (JSON string).",
+							Description: "Content of the tab (JSON string). Leave unset when the tab is described with refresh, filter and widget blocks.",
 						},
 						"uuid": &schema.Schema{
 							Type:        schema.TypeString,
@@ -100,13 +141,106 @@ func resourceDashboards() *schema.Resource {
 							Computed:    true,
 							Description: "Creator ID of the tab.",
 						},
-					},
+					}),
 				},
 			},
 		},
 	}
 }
 
+// resourceDashboardsCustomizeDiff replaces the dashboard when the content of
+// source_file changes, not only its path.
+func resourceDashboardsCustomizeDiff(d *schema.ResourceDiff, m interface{}) error {
+	v, ok := d.GetOk("source_file")
+	if !ok || !d.NewValueKnown("source_file") {
+		return nil
+	}
+	sum, err := fileSHA256(v.(string))
+	if err != nil {
+		return fmt.Errorf("source_file: %s", err)
+	}
+	if old, _ := d.GetChange("source_file_sha256"); old.(string) == sum {
+		return nil
+	}
+	if err := d.SetNew("source_file_sha256", sum); err != nil {
+		return err
+	}
+	if d.Id() != "" {
+		return d.ForceNew("source_file_sha256")
+	}
+	return nil
+}
+
+// resourceDashboardsTokenDiff checks the $token$ references of every typed tab.
+func resourceDashboardsTokenDiff(d *schema.ResourceDiff, m interface{}) error {
+	tabs, _ := d.Get("tabs").([]interface{})
+	for _, t := range tabs {
+		tab, ok := t.(map[string]interface{})
+		if !ok || !tabHasTypedContent(tab) {
+			continue
+		}
+		if err := checkDashboardTabReferences(tab); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importDashboardSource creates the tabs of the source_file package on a new
+// dashboard, importing the trends they reference first.
+func importDashboardSource(c *yottaweb.Client, d *schema.ResourceData, dashboardID string) error {
+	sourceFile := d.Get("source_file").(string)
+	pkg, err := readDashboardPackage(sourceFile)
+	if err != nil {
+		return err
+	}
+	idMap, created, err := importPackageTrends(c, pkg, d.Get("trend_import_mode").(string) == "reuse")
+	d.Set("imported_trend_ids", idMap)
+	d.Set("created_trend_ids", created)
+	if err != nil {
+		return err
+	}
+
+	for _, tab := range pkg.Tabs {
+		name := stringValue(tab["name"])
+		content := stringValue(tab["content"])
+		if content == "" {
+			// 部分导出文件中 content 为对象而非字符串
+			if b, err := json.Marshal(tab["content"]); err == nil {
+				content = string(b)
+			}
+		}
+		content, err := rewriteTabTrendIDs(content, idMap)
+		if err != nil {
+			return fmt.Errorf("invalid content of tab %s in %s: %s", name, sourceFile, err)
+		}
+		endpoint := c.BuildRizhiyiURL(nil, "..", "v3", "dashboards", dashboardID, "tabs")
+		resp, err := c.Post(endpoint, map[string]interface{}{
+			"name":    name,
+			"content": content,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create tab %s: %s", name, err)
+		}
+		resp.Body.Close()
+	}
+
+	sum, err := fileSHA256(sourceFile)
+	if err != nil {
+		return err
+	}
+	d.Set("source_file_sha256", sum)
+	return nil
+}
+
+// dashboardTabSchema adds the typed content blocks to a tab schema.
+func dashboardTabSchema(s map[string]*schema.Schema) map[string]*schema.Schema {
+	for k, v := range dashboardTabTypedSchema() {
+		s[k] = v
+	}
+	return s
+}
+
 func suppressEquivalentJSON(k, old, new string, d *schema.ResourceData) bool {
 	var jo interface{}
 	var jn interface{}
@@ -189,27 +323,16 @@ func resourceDashboardsCreate(d *schema.ResourceData, m interface{}) error {
 	
 	d.SetId(dashboardIDStr)
 
-	// Create Tabs
-	if d.Get("manage_tabs").(bool) {
-		if v, ok := d.GetOk("tabs"); ok {
-		tabs := v.([]interface{})
-		for _, t := range tabs {
-			tab := t.(map[string]interface{})
-			tabName := tab["name"].(string)
-			tabContent := tab["content"].(string)
-
-			tabBody := map[string]interface{}{
-				"name":    tabName,
-				"content": tabContent,
-			}
-			// POST /api/v3/dashboards/{dashboard_id}/tabs/
-			tabEndpoint := c.BuildRizhiyiURL(nil, "..", "v3", "dashboards", dashboardIDStr, "tabs")
-			tabResp, err := c.Post(tabEndpoint, tabBody)
-			if err != nil {
-				return fmt.Errorf("failed to create tab %s: %s", tabName, err)
-			}
-			tabResp.Body.Close()
+	if _, ok := d.GetOk("source_file"); ok {
+		if err := importDashboardSource(c, d, dashboardIDStr); err != nil {
+			return err
 		}
+	}
+
+	// Create Tabs; tabs imported from source_file are left as they are
+	if d.Get("manage_tabs").(bool) && d.Get("source_file").(string) == "" {
+		if err := reconcileDashboardTabs(c, d, dashboardIDStr); err != nil {
+			return err
 		}
 	}
 
@@ -292,22 +415,41 @@ func resourceDashboardsRead(d *schema.ResourceData, m interface{}) error {
 		}
 	}
 
-	// Process Tabs from dashboard object
-	var tabsList []interface{}
-	if v, ok := dashboard["tabs"].([]interface{}); ok {
-		tabsList = v
-	}
-
 	if d.Get("manage_tabs").(bool) {
-		tfTabs := make([]map[string]interface{}, 0, len(tabsList))
-		for _, t := range tabsList {
-			tabMap := t.(map[string]interface{})
+		// 仅对使用 refresh/filter/widget 块描述的 tab 回填结构化字段
+		typedTabs := make(map[string]map[string]interface{})
+		for _, t := range d.Get("tabs").([]interface{}) {
+			if tab, ok := t.(map[string]interface{}); ok && tabHasTypedContent(tab) {
+				typedTabs[stringValue(tab["name"])] = tab
+			}
+		}
+
+		tfTabs := make([]map[string]interface{}, 0)
+		for _, tabMap := range orderedDashboardTabs(dashboard) {
 			tfTab := map[string]interface{}{
-				"id":         int(tabMap["id"].(float64)),
-				"name":       tabMap["name"].(string),
-				"content":    tabMap["content"].(string),
-				"uuid":       tabMap["uuid"].(string),
-				"creator_id": int(tabMap["creator_id"].(float64)),
+				"id":         0,
+				"name":       stringValue(tabMap["name"]),
+				"content":    stringValue(tabMap["content"]),
+				"uuid":       stringValue(tabMap["uuid"]),
+				"creator_id": 0,
+			}
+			if v, ok := toInt(tabMap["id"]); ok {
+				tfTab["id"] = v
+			}
+			if v, ok := toInt(tabMap["creator_id"]); ok {
+				tfTab["creator_id"] = v
+			}
+			if tab, ok := typedTabs[tfTab["name"].(string)]; ok {
+				typed, err := flattenDashboardTabContent(tfTab["name"].(string), tfTab["content"].(string))
+				if err != nil {
+					return fmt.Errorf("failed to decode content of tab %s: %s", tfTab["name"], err)
+				}
+				if v, _ := tab["refresh"].([]interface{}); len(v) == 0 {
+					delete(typed, "refresh")
+				}
+				for k, v := range typed {
+					tfTab[k] = v
+				}
 			}
 			tfTabs = append(tfTabs, tfTab)
 		}
@@ -341,110 +483,143 @@ func resourceDashboardsUpdate(d *schema.ResourceData, m interface{}) error {
 
 	// Update Tabs
 	if d.Get("manage_tabs").(bool) && d.HasChange("tabs") {
-		// 1. Get current state (we can re-read or trust Read was called)
-		// Better to fetch current tabs to be safe
-		readEndpoint := c.BuildRizhiyiURL(nil, "..", "v3", "dashboards", id)
-		readResp, err := c.Get(readEndpoint)
-		if err != nil {
+		if err := reconcileDashboardTabs(c, d, id); err != nil {
 			return err
 		}
-		defer readResp.Body.Close()
-		
-		readBytes, _ := io.ReadAll(readResp.Body)
-		var readResult map[string]interface{}
-		json.Unmarshal(readBytes, &readResult)
-		
-		type tabInfo struct {
-			id   int
-			name string
-		}
-		existingTabs := make([]tabInfo, 0)
-		if obj, ok := readResult["object"].(map[string]interface{}); ok {
-			if tabs, ok := obj["tabs"].([]interface{}); ok {
-				for _, t := range tabs {
-					tm := t.(map[string]interface{})
-					existingTabs = append(existingTabs, tabInfo{
-						id:   int(tm["id"].(float64)),
-						name: tm["name"].(string),
-					})
-				}
+	}
+
+	return resourceDashboardsRead(d, m)
+}
+
+// reconcileDashboardTabs makes the tabs of a dashboard match the tabs blocks.
+// Configured tabs are matched to existing tabs by name first, then by the uuid the
+// tab at the same position had in state (a rename). Matched tabs are updated in
+// place so their id and uuid survive, the rest are created or deleted, and the
+// configured order is written to sequences.
+func reconcileDashboardTabs(c *yottaweb.Client, d *schema.ResourceData, dashboardID string) error {
+	dashboard, err := getDashboardObject(c, dashboardID)
+	if err != nil {
+		return err
+	}
+	if dashboard == nil {
+		return fmt.Errorf("dashboard %s not found", dashboardID)
+	}
+	existing := orderedDashboardTabs(dashboard)
+	currentOrder := make([]string, 0, len(existing))
+	for _, tab := range existing {
+		currentOrder = append(currentOrder, idString(tab["id"]))
+	}
+
+	desired := d.Get("tabs").([]interface{})
+	oldRaw, _ := d.GetChange("tabs")
+	oldTabs, _ := oldRaw.([]interface{})
+
+	matched := make([]map[string]interface{}, len(desired))
+	used := make(map[string]bool, len(existing))
+	for i, t := range desired {
+		name := stringValue(t.(map[string]interface{})["name"])
+		for _, tab := range existing {
+			if id := idString(tab["id"]); !used[id] && stringValue(tab["name"]) == name {
+				matched[i], used[id] = tab, true
+				break
 			}
 		}
-
-		// 2. Process desired tabs
-		newTabs := d.Get("tabs").([]interface{})
-		matchedExistingIDs := make(map[int]bool)
-
-		for _, t := range newTabs {
-			tab := t.(map[string]interface{})
-			name := tab["name"].(string)
-			content := tab["content"].(string)
-			desiredID := 0
-			if v, ok := tab["id"]; ok {
-				switch dv := v.(type) {
-				case int:
-					desiredID = dv
-				case float64:
-					desiredID = int(dv)
-				}
+	}
+	for i := range desired {
+		if matched[i] != nil || i >= len(oldTabs) {
+			continue
+		}
+		old, _ := oldTabs[i].(map[string]interface{})
+		uuid := stringValue(old["uuid"])
+		if uuid == "" {
+			continue
+		}
+		for _, tab := range existing {
+			if id := idString(tab["id"]); !used[id] && stringValue(tab["uuid"]) == uuid {
+				matched[i], used[id] = tab, true
+				break
 			}
+		}
+	}
 
-			tabBody := map[string]interface{}{
-				"name":    name,
-				"content": content,
-			}
+	order := make([]string, 0, len(desired))
+	for i, t := range desired {
+		tab := t.(map[string]interface{})
+		name := tab["name"].(string)
+		content, err := dashboardTabContent(tab)
+		if err != nil {
+			return err
+		}
+		tabBody := map[string]interface{}{
+			"name":    name,
+			"content": content,
+		}
 
-			var targetID int
-			if desiredID > 0 {
-				targetID = desiredID
-			} else {
-				// find first unmatched existing by name
-				for _, et := range existingTabs {
-					if et.name == name && !matchedExistingIDs[et.id] {
-						targetID = et.id
-						break
-					}
-				}
+		if current := matched[i]; current != nil {
+			tabID := idString(current["id"])
+			order = append(order, tabID)
+			if stringValue(current["name"]) == name && suppressEquivalentJSON("", stringValue(current["content"]), content, nil) {
+				continue
 			}
+			// PUT /api/v3/dashboards/{did}/tabs/{tid}/
+			endpoint := c.BuildRizhiyiURL(nil, "..", "v3", "dashboards", dashboardID, "tabs", tabID)
+			resp, err := c.Put(endpoint, tabBody)
+			if err != nil {
+				return fmt.Errorf("failed to update tab %s: %s", name, err)
+			}
+			resp.Body.Close()
+			continue
+		}
 
-			if targetID > 0 {
-				// Update
-				// PUT /api/v3/dashboards/{did}/tabs/{tid}/
-				tabIDStr := strconv.Itoa(targetID)
-				updEndpoint := c.BuildRizhiyiURL(nil, "..", "v3", "dashboards", id, "tabs", tabIDStr)
-				if r, e := c.Put(updEndpoint, tabBody); e == nil {
-					r.Body.Close()
-				} else {
-					return fmt.Errorf("failed to update tab %s: %s", name, e)
-				}
-				matchedExistingIDs[targetID] = true
-			} else {
-				// Create
-				createEndpoint := c.BuildRizhiyiURL(nil, "..", "v3", "dashboards", id, "tabs")
-				if r, e := c.Post(createEndpoint, tabBody); e == nil {
-					r.Body.Close()
-				} else {
-					return fmt.Errorf("failed to create tab %s: %s", name, e)
+		// POST /api/v3/dashboards/{did}/tabs/
+		endpoint := c.BuildRizhiyiURL(nil, "..", "v3", "dashboards", dashboardID, "tabs")
+		resp, err := c.Post(endpoint, tabBody)
+		if err != nil {
+			return fmt.Errorf("failed to create tab %s: %s", name, err)
+		}
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		tabID := idFromCreateResponse(bodyBytes)
+		if tabID == "" {
+			// 响应中没有 id 时，按名称查找尚未匹配的新 tab
+			refreshed, err := getDashboardObject(c, dashboardID)
+			if err != nil {
+				return err
+			}
+			for _, created := range dashboardTabs(refreshed) {
+				if id := idString(created["id"]); !used[id] && stringValue(created["name"]) == name {
+					tabID = id
+					break
 				}
 			}
 		}
+		if tabID == "" {
+			return fmt.Errorf("tab %s created but id not resolvable", name)
+		}
+		used[tabID] = true
+		order = append(order, tabID)
+	}
 
-		// 3. Delete removed tabs
-		for _, et := range existingTabs {
-			if !matchedExistingIDs[et.id] {
-				// Delete
-				tabIDStr := strconv.Itoa(et.id)
-				delEndpoint := c.BuildRizhiyiURL(nil, "..", "v3", "dashboards", id, "tabs", tabIDStr)
-				if r, e := c.Delete(delEndpoint); e == nil {
-					r.Body.Close()
-				} else {
-					return fmt.Errorf("failed to delete tab id=%d name=%s: %s", et.id, et.name, e)
-				}
+	for _, tab := range existing {
+		tabID := idString(tab["id"])
+		if used[tabID] {
+			continue
+		}
+		endpoint := c.BuildRizhiyiURL(nil, "..", "v3", "dashboards", dashboardID, "tabs", tabID)
+		resp, err := c.Delete(endpoint)
+		if err != nil {
+			if strings.Contains(err.Error(), "404") {
+				continue
 			}
+			return fmt.Errorf("failed to delete tab id=%s name=%s: %s", tabID, stringValue(tab["name"]), err)
 		}
+		resp.Body.Close()
 	}
 
-	return resourceDashboardsRead(d, m)
+	if !reflect.DeepEqual(order, currentOrder) {
+		return setDashboardSequences(c, dashboardID, dashboard, order)
+	}
+	return nil
 }
 
 func resourceDashboardsDelete(d *schema.ResourceData, m interface{}) error {
@@ -457,6 +632,19 @@ func resourceDashboardsDelete(d *schema.ResourceData, m interface{}) error {
 		return err
 	}
 	resp.Body.Close()
+
+	// 删除导入 source_file 时创建的 trend
+	for _, trendID := range d.Get("created_trend_ids").([]interface{}) {
+		trendEndpoint := c.BuildRizhiyiURL(nil, "v3", "trends", trendID.(string))
+		trendResp, err := c.Delete(trendEndpoint)
+		if err != nil {
+			if strings.Contains(err.Error(), "404") {
+				continue
+			}
+			return fmt.Errorf("failed to delete imported trend %s: %s", trendID, err)
+		}
+		trendResp.Body.Close()
+	}
 	d.SetId("")
 	return nil
 }
@@ -1,12 +1,15 @@
 package provider
 
 import (
+	"github.com/hashicorp/terraform-plugin-sdk/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 	"net/url"
 	"terraform-provider-rizhiyi/yottaweb"
 	"encoding/json"
+	"fmt"
 	"io"
 	"strconv"
+	"strings"
 )
 
 func resourceIndex() *schema.Resource {
@@ -16,21 +19,26 @@ func resourceIndex() *schema.Resource {
 		Update: resourceIndexesUpdate,
 		Delete: resourceIndexesDelete,
 
+		CustomizeDiff: customdiff.Sequence(resourceIndexLifecycleDiff, resourceIndexFieldDiff),
+
 		Schema: map[string]*schema.Schema{
 			"advanced_strategy": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
-				Description: "",
+				DiffSuppressFunc: suppressIndexAdvancedStrategy,
+				Description: "Storage tier settings (JSON string); cleared when unset. Ignored while storage_lifecycle is set. Prefer storage_lifecycle.",
 			},
 			"pattern": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
-				Description: "Index info mode, value: 'kCompression' for compression mode, default enabling forward optimization/forward compression; 'kNumeric' for numeric mode, default enabling forward optimization/dropping some built-in fields; 'kNormal' for custom mode.",
+				ForceNew: true,
+				Description: "Index info mode, value: 'kCompression' for compression mode, default enabling forward optimization/forward compression; 'kNumeric' for numeric mode, default enabling forward optimization/dropping some built-in fields; 'kNormal' for custom mode. Changing it replaces the index.",
 			},
 			"name": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
-				Description: "Index info name",
+				ForceNew: true,
+				Description: "Index info name. Changing it replaces the index.",
 			},
 			"description": &schema.Schema{
 				Type:     schema.TypeString,
@@ -52,18 +60,21 @@ func resourceIndex() *schema.Resource {
 			"expired_time": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
+				DiffSuppressFunc: suppressEquivalentIndexDuration,
 				Description: "Retention time for Index info resource, for example: 10d.",
 			},
 
 			"rotation_period": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
+				DiffSuppressFunc: suppressEquivalentIndexDuration,
 				Description: "Partitioning time for Index info resource, for example: 5d.",
 			},
 			"sink_to_nas": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
-				Description: "",
+				DiffSuppressFunc: suppressIndexStorageDuration,
+				Description: "Age at which data moves to NAS, for example: 30d; cleared when unset. Ignored while storage_lifecycle is set. Prefer storage_lifecycle.",
 			},
 			"domain_id": &schema.Schema{
 				Type:     schema.TypeInt,
@@ -74,28 +85,35 @@ func resourceIndex() *schema.Resource {
 			"sink_to_hdd": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
-				Description: "",
+				DiffSuppressFunc: suppressIndexStorageDuration,
+				Description: "Age at which data moves to HDD, for example: 7d; cleared when unset. Ignored while storage_lifecycle is set. Prefer storage_lifecycle.",
 			},
 			"discard_stored_field": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
-				Description: "Forward optimization of Index info.",
+				Description: "Forward optimization of Index info. Prefer field.",
 			},
 			"index_name_pattern": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
-				Description: "",
+				ForceNew: true,
+				Description: "Changing it replaces the index.",
 			},
 
 			"discard_backup": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
+				DiffSuppressFunc: suppressIndexStorageField,
+				Description: "Whether replicas are dropped when data leaves the hot tier: true or false; false when unset. Ignored while storage_lifecycle is set. Prefer storage_lifecycle.",
 			},
 			"freeze": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
-				Description: "",
+				DiffSuppressFunc: suppressIndexStorageDuration,
+				Description: "Age at which data is frozen, for example: 60d; cleared when unset. Ignored while storage_lifecycle is set. Prefer storage_lifecycle.",
 			},
+			"storage_lifecycle": indexLifecycleSchema(),
+			"field":             indexFieldSchema(),
 
 			"change_disabled_state": &schema.Schema{
 				Type:     schema.TypeBool,
@@ -122,40 +140,187 @@ func resourceIndex() *schema.Resource {
 				Type:     schema.TypeMap,
 				Elem:     &schema.Schema{Type: schema.TypeString},
 				Optional: true,
-				Description: "Indexinfo tokenized fields, currently only standard tokenization (i.e., 'standard' attribute). Fields can be separated by a comma.",
+				Description: "Indexinfo tokenized fields, currently only standard tokenization (i.e., 'standard' attribute). Fields can be separated by a comma. Prefer field.",
+			},
+			"deletion_protection": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+				Description: "Whether Terraform refuses to delete or replace the index. Set it to false and force_destroy to true, and apply, before the index and its data can be removed. (default value true)",
+			},
+			"force_destroy": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				Description: "Confirms that deleting the index also drops all of its data. Required together with deletion_protection = false. (default value false)",
 			},
 		},
 	}
 }
 
+// indexRequestBody maps the schema onto the index API object; Create and Update
+// share it so both send the same fields.
+func indexRequestBody(d *schema.ResourceData) (map[string]interface{}, error) {
+	pattern := d.Get("pattern").(string)
+	requestBody := map[string]interface{}{
+		"pattern":              pattern,
+		"name":                 d.Get("name").(string),
+		"description":          d.Get("description").(string),
+		"disabled":             d.Get("disabled").(int) != 0,
+		"expired":              d.Get("expired_time").(string),
+		"rotation_period":      d.Get("rotation_period").(string),
+		"number_of_replicas":   d.Get("number_of_replicas").(int),
+		"domain_id":            d.Get("domain_id").(int),
+		"index_name_pattern":   d.Get("index_name_pattern").(string),
+		"discard_stored_field": d.Get("discard_stored_field").(string),
+		"use_zstd_compress":    d.Get("use_zstd_compress").(bool),
+		"reduce_inner_fields":  d.Get("reduce_inner_fields").(bool),
+		"inject_reduce":        d.Get("inject_reduce").(map[string]interface{}),
+		"tokenizer":            d.Get("tokenizer").(map[string]interface{}),
+	}
+	if d.Get("change_disabled_state").(bool) {
+		requestBody["change_disabled_state"] = true
+	}
+	if err := setIndexStorageFields(d, requestBody); err != nil {
+		return nil, err
+	}
+	if fields := d.Get("field").([]interface{}); len(fields) > 0 {
+		if err := checkIndexFields(pattern, fields); err != nil {
+			return nil, err
+		}
+		requestBody["fields"] = expandIndexFields(fields)
+	} else if !indexLegacyFieldMapping(d) {
+		// 删除全部 field 块时清空平台上的字段映射
+		requestBody["fields"] = []map[string]interface{}{}
+	}
+	return requestBody, nil
+}
+
+// setIndexFromResponse maps the index API object back onto the schema, the
+// inverse of indexRequestBody.
+func setIndexFromResponse(d *schema.ResourceData, data map[string]interface{}) {
+	d.Set("name", data["name"])
+	d.Set("pattern", data["pattern"])
+	d.Set("description", stringValue(data["description"]))
+	if indexBool(data["disabled"]) {
+		d.Set("disabled", 1)
+	} else {
+		d.Set("disabled", 0)
+	}
+	setIndexDuration(d, "expired_time", data["expired"])
+	setIndexDuration(d, "rotation_period", data["rotation_period"])
+	if v, ok := toInt(data["number_of_replicas"]); ok {
+		d.Set("number_of_replicas", v)
+	}
+	if v, ok := toInt(data["domain_id"]); ok {
+		d.Set("domain_id", v)
+	}
+	d.Set("index_name_pattern", stringValue(data["index_name_pattern"]))
+	d.Set("discard_stored_field", stringValue(data["discard_stored_field"]))
+	d.Set("use_zstd_compress", indexBool(data["use_zstd_compress"]))
+	d.Set("reduce_inner_fields", indexBool(data["reduce_inner_fields"]))
+	d.Set("inject_reduce", indexStringMap(data["inject_reduce"]))
+	d.Set("tokenizer", indexStringMap(data["tokenizer"]))
+
+	setIndexDuration(d, "sink_to_hdd", data["sink_to_hdd"])
+	setIndexDuration(d, "sink_to_nas", data["sink_to_nas"])
+	setIndexDuration(d, "freeze", data["freeze"])
+	d.Set("advanced_strategy", indexAdvancedStrategy(data["advanced_strategy"]))
+	d.Set("discard_backup", strconv.FormatBool(indexBool(data["discard_backup"])))
+	if len(d.Get("storage_lifecycle").([]interface{})) > 0 {
+		d.Set("storage_lifecycle", flattenIndexLifecycle(data))
+	}
+	if !indexLegacyFieldMapping(d) {
+		d.Set("field", flattenIndexFields(data["fields"]))
+	}
+}
+
+// indexLegacyFieldMapping reports whether the field mapping is managed through
+// tokenizer and discard_stored_field instead of field blocks.
+func indexLegacyFieldMapping(d *schema.ResourceData) bool {
+	return len(d.Get("tokenizer").(map[string]interface{})) > 0 || d.Get("discard_stored_field").(string) != ""
+}
+
+// setIndexDuration sets a duration attribute, keeping the configured spelling
+// when the platform returns an equivalent one (10d vs 240h).
+func setIndexDuration(d *schema.ResourceData, key string, v interface{}) {
+	s := stringValue(v)
+	if old := d.Get(key).(string); old != "" && indexDurationsEqual(old, s) {
+		return
+	}
+	d.Set(key, s)
+}
+
+func indexDurationsEqual(a, b string) bool {
+	if a == b {
+		return true
+	}
+	da, errA := parseIndexDuration(a)
+	db, errB := parseIndexDuration(b)
+	return errA == nil && errB == nil && da == db
+}
+
+func suppressEquivalentIndexDuration(k, old, new string, d *schema.ResourceData) bool {
+	return indexDurationsEqual(old, new)
+}
+
+// suppressIndexStorageField hides the flat storage tier fields while
+// storage_lifecycle, which sets them, is configured. discard_backup reads back
+// as false when unset.
+func suppressIndexStorageField(k, old, new string, d *schema.ResourceData) bool {
+	if len(d.Get("storage_lifecycle").([]interface{})) > 0 {
+		return true
+	}
+	return k == "discard_backup" && old == "false" && new == ""
+}
+
+func suppressIndexAdvancedStrategy(k, old, new string, d *schema.ResourceData) bool {
+	return suppressIndexStorageField(k, old, new, d) || suppressEquivalentJSON(k, old, new, d)
+}
+
+// indexAdvancedStrategy returns advanced_strategy as a JSON string; the API
+// returns it either encoded or as an object.
+func indexAdvancedStrategy(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case map[string]interface{}:
+		if len(val) == 0 {
+			return ""
+		}
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func suppressIndexStorageDuration(k, old, new string, d *schema.ResourceData) bool {
+	return suppressIndexStorageField(k, old, new, d) || indexDurationsEqual(old, new)
+}
+
+// indexStringMap reads a map attribute the index API may return with
+// non-string values or as null.
+func indexStringMap(v interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	if obj, ok := v.(map[string]interface{}); ok {
+		for k, val := range obj {
+			if s, ok := val.(string); ok {
+				result[k] = s
+			} else if val != nil {
+				result[k] = fmt.Sprintf("%v", val)
+			}
+		}
+	}
+	return result
+}
+
 func resourceIndexesCreate(d *schema.ResourceData, m interface{}) error {
 	c := m.(*yottaweb.Client)
-	pattern := d.Get("pattern").(string)
 	name := d.Get("name").(string)
-	description := d.Get("description").(string)
-	disabled := d.Get("disabled").(int)
-	expired_time := d.Get("expired_time").(string)
-	rotation_period := d.Get("rotation_period").(string)
-	number_of_replicas := d.Get("number_of_replicas").(int)
-	domain_id := d.Get("domain_id").(int)
-	index_name_pattern := d.Get("index_name_pattern").(string)
-	discard_stored_field := d.Get("discard_stored_field").(string)
-	use_zstd_compress := d.Get("use_zstd_compress").(bool)
-	reduce_inner_fields := d.Get("reduce_inner_fields").(bool)
-
-	requestBody := map[string]interface{}{
-		"pattern":               pattern,
-		"name":                  name,
-		"description":           description,
-		"disabled":              disabled != 0,
-		"expired":               expired_time,
-		"rotation_period":       rotation_period,
-		"number_of_replicas":    number_of_replicas,
-		"domain_id":             domain_id,
-		"index_name_pattern":    index_name_pattern,
-		"discard_stored_field":  discard_stored_field,
-		"use_zstd_compress":     use_zstd_compress,
-		"reduce_inner_fields":   reduce_inner_fields,
+	requestBody, err := indexRequestBody(d)
+	if err != nil {
+		return err
 	}
 
 	endpoint := c.BuildRizhiyiURL(nil, "..", "v3", "indexes")
@@ -166,36 +331,16 @@ func resourceIndexesCreate(d *schema.ResourceData, m interface{}) error {
 
 	defer resp.Body.Close()
 	bodyBytes, _ := io.ReadAll(resp.Body)
-	var respData map[string]interface{}
-	if err := json.Unmarshal(bodyBytes, &respData); err == nil {
-		if obj, ok := respData["object"]; ok {
-			switch v := obj.(type) {
-			case float64:
-				d.SetId(strconv.Itoa(int(v)))
-			case map[string]interface{}:
-				if idv, ok := v["id"]; ok {
-					switch iv := idv.(type) {
-					case float64:
-						d.SetId(strconv.Itoa(int(iv)))
-					case string:
-						d.SetId(iv)
-					case int:
-						d.SetId(strconv.Itoa(iv))
-					default:
-						d.SetId("")
-					}
-				}
-			case string:
-				d.SetId(v)
-			}
-		}
-	}
+	d.SetId(idFromCreateResponse(bodyBytes))
 	if d.Id() == "" {
 		if rid, _ := c.GetResourceIdByName(name, "..", "v3", "indexes"); rid != "" {
 			d.SetId(rid)
 		}
 	}
-	return nil
+	if d.Id() == "" {
+		return fmt.Errorf("index created but id not resolvable: %s", name)
+	}
+	return resourceIndexesRead(d, m)
 }
 
 func resourceIndexesRead(d *schema.ResourceData, m interface{}) error {
@@ -232,67 +377,37 @@ func resourceIndexesRead(d *schema.ResourceData, m interface{}) error {
 
 	data, err := c.GetResourceById(id, "..", "v3", "indexes")
 	if err != nil {
-		return err
-	}
-
-	d.Set("name", data["name"])
-	d.Set("description", data["description"])
-	if disabled, ok := data["disabled"].(bool); ok {
-		if disabled {
-			d.Set("disabled", 1)
-		} else {
-			d.Set("disabled", 0)
+		if strings.Contains(err.Error(), "404") {
+			d.SetId("")
+			return nil
 		}
+		return err
 	}
-	d.Set("rotation_period", data["rotation_period"])
-	d.Set("expired_time", data["expired"])
-	d.Set("pattern", data["pattern"])
-	d.Set("domain_id", data["domain_id"])
-	d.Set("number_of_replicas", data["number_of_replicas"])
-	d.Set("index_name_pattern", data["index_name_pattern"])
-	d.Set("discard_stored_field", data["discard_stored_field"])
-	d.Set("use_zstd_compress", data["use_zstd_compress"])
-	d.Set("reduce_inner_fields", data["reduce_inner_fields"])
-	d.Set("freeze", data["freeze"])
-	d.Set("sink_to_nas", data["sink_to_nas"])
-	d.Set("sink_to_hdd", data["sink_to_hdd"])
-	d.Set("discard_backup", data["discard_backup"])
 
+	setIndexFromResponse(d, data)
 	return nil
 }
 
+// indexAPIFields are the attributes sent to the platform; deletion_protection and
+// force_destroy only live in state.
+var indexAPIFields = []string{
+	"description", "disabled", "expired_time", "rotation_period", "number_of_replicas",
+	"domain_id", "discard_stored_field", "use_zstd_compress", "reduce_inner_fields",
+	"inject_reduce", "tokenizer", "change_disabled_state",
+	"sink_to_hdd", "sink_to_nas", "freeze", "advanced_strategy", "discard_backup", "storage_lifecycle", "field",
+}
+
 func resourceIndexesUpdate(d *schema.ResourceData, m interface{}) error {
 	c := m.(*yottaweb.Client)
-	pattern := d.Get("pattern").(string)
-	name := d.Get("name").(string)
-	description := d.Get("description").(string)
-	disabled := d.Get("disabled").(int)
-	expired_time := d.Get("expired_time").(string)
-	rotation_period := d.Get("rotation_period").(string)
-	number_of_replicas := d.Get("number_of_replicas").(int)
-	domain_id := d.Get("domain_id").(int)
-	index_name_pattern := d.Get("index_name_pattern").(string)
-	discard_stored_field := d.Get("discard_stored_field").(string)
-	use_zstd_compress := d.Get("use_zstd_compress").(bool)
-	reduce_inner_fields := d.Get("reduce_inner_fields").(bool)
-
-	requestBody := map[string]interface{}{
-		"pattern":               pattern,
-		"name":                  name,
-		"description":           description,
-		"disabled":              disabled != 0,
-		"expired":               expired_time,
-		"rotation_period":       rotation_period,
-		"number_of_replicas":    number_of_replicas,
-		"domain_id":             domain_id,
-		"index_name_pattern":    index_name_pattern,
-		"discard_stored_field":  discard_stored_field,
-		"use_zstd_compress":     use_zstd_compress,
-		"reduce_inner_fields":   reduce_inner_fields,
+	if !d.HasChanges(indexAPIFields...) {
+		return nil
+	}
+	requestBody, err := indexRequestBody(d)
+	if err != nil {
+		return err
 	}
 
-	update_id := d.Id()
-	endpoint := c.BuildRizhiyiURL(nil, "..", "v3", "indexes", update_id)
+	endpoint := c.BuildRizhiyiURL(nil, "..", "v3", "indexes", d.Id())
 	resp, err := c.Put(endpoint, requestBody)
 	if err != nil {
 		return err
@@ -300,7 +415,7 @@ func resourceIndexesUpdate(d *schema.ResourceData, m interface{}) error {
 
 	defer resp.Body.Close()
 
-	return nil
+	return resourceIndexesRead(d, m)
 }
 
 func resourceIndexesDelete(d *schema.ResourceData, m interface{}) error {
@@ -308,6 +423,14 @@ func resourceIndexesDelete(d *schema.ResourceData, m interface{}) error {
 	name := d.Get("name").(string)
 	del_id := d.Id()
 
+	// 删除索引会同时删除其中全部数据，必须显式确认
+	if d.Get("deletion_protection").(bool) {
+		return fmt.Errorf("index %s has deletion_protection enabled; set deletion_protection = false and force_destroy = true and apply before destroying or replacing it", name)
+	}
+	if !d.Get("force_destroy").(bool) {
+		return fmt.Errorf("deleting index %s drops all of its data; set force_destroy = true and apply before destroying or replacing it", name)
+	}
+
 	// build delete index parameters
 	parametersValues := url.Values{}
 	parametersValues.Add("engine", "beaver")
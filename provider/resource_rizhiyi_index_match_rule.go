@@ -0,0 +1,215 @@
+package provider
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"terraform-provider-rizhiyi/yottaweb"
+)
+
+// indexMatchFields are the event attributes a match rule can route on.
+var indexMatchFields = []string{"appname", "tag", "hostname", "source"}
+
+func resourceIndexMatchRule() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceIndexMatchRuleCreate,
+		Read:   resourceIndexMatchRuleRead,
+		Update: resourceIndexMatchRuleUpdate,
+		Delete: resourceIndexMatchRuleDelete,
+
+		Schema: map[string]*schema.Schema{
+			"index_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "ID of the index events are routed to, for example rizhiyi_index.x.id.",
+			},
+			"index_name": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Name of the index events are routed to.",
+			},
+			"appname": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				AtLeastOneOf: indexMatchFields,
+				Description:  "Appname pattern matched by the rule, for example: nginx or nginx_*.",
+			},
+			"tag": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				AtLeastOneOf: indexMatchFields,
+				Description:  "Tag pattern matched by the rule.",
+			},
+			"hostname": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				AtLeastOneOf: indexMatchFields,
+				Description:  "Hostname pattern matched by the rule.",
+			},
+			"source": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				AtLeastOneOf: indexMatchFields,
+				Description:  "Source pattern matched by the rule, for example: /var/log/nginx/*.log.",
+			},
+			"priority": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Priority of the rule; when several rules match an event, the one with the highest priority wins. (default value 0)",
+			},
+			"description": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Description of the rule.",
+			},
+			"disabled": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether the rule is disabled. (default value false)",
+			},
+		},
+	}
+}
+
+func indexMatchRuleRequestBody(d *schema.ResourceData) (map[string]interface{}, error) {
+	indexID, err := strconv.Atoi(d.Get("index_id").(string))
+	if err != nil {
+		return nil, fmt.Errorf("index_id must be numeric, got %q", d.Get("index_id").(string))
+	}
+	requestBody := map[string]interface{}{
+		"index_id":    indexID,
+		"priority":    d.Get("priority").(int),
+		"description": d.Get("description").(string),
+		"disabled":    d.Get("disabled").(bool),
+	}
+	for _, k := range indexMatchFields {
+		requestBody[k] = d.Get(k).(string)
+	}
+	return requestBody, nil
+}
+
+// findIndexMatchRule looks up a rule by its index and match patterns, used when
+// the create response carries no ID since rules have no name.
+func findIndexMatchRule(c *yottaweb.Client, body map[string]interface{}) (string, error) {
+	rules, err := listResources(c, "..", "v3", "indexmatchrules")
+	if err != nil {
+		return "", err
+	}
+	for _, rule := range rules {
+		if idString(rule["index_id"]) != idString(body["index_id"]) {
+			continue
+		}
+		matched := true
+		for _, k := range indexMatchFields {
+			if stringValue(rule[k]) != body[k].(string) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return idString(rule["id"]), nil
+		}
+	}
+	return "", nil
+}
+
+func resourceIndexMatchRuleCreate(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	requestBody, err := indexMatchRuleRequestBody(d)
+	if err != nil {
+		return err
+	}
+
+	endpoint := c.BuildRizhiyiURL(nil, "..", "v3", "indexmatchrules")
+	resp, err := c.Post(endpoint, requestBody)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	d.SetId(idFromCreateResponse(bodyBytes))
+	if d.Id() == "" {
+		if rid, _ := findIndexMatchRule(c, requestBody); rid != "" {
+			d.SetId(rid)
+		}
+	}
+	if d.Id() == "" {
+		return fmt.Errorf("index match rule created but id not resolvable: %s", string(bodyBytes))
+	}
+	return resourceIndexMatchRuleRead(d, m)
+}
+
+func resourceIndexMatchRuleRead(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	id := d.Id()
+	if id == "" {
+		return nil
+	}
+
+	data, err := c.GetResourceById(id, "..", "v3", "indexmatchrules")
+	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("index_id", idString(data["index_id"]))
+	d.Set("index_name", stringValue(data["index_name"]))
+	for _, k := range indexMatchFields {
+		d.Set(k, stringValue(data[k]))
+	}
+	if v, ok := toInt(data["priority"]); ok {
+		d.Set("priority", v)
+	}
+	d.Set("description", stringValue(data["description"]))
+	if v, ok := data["disabled"].(bool); ok {
+		d.Set("disabled", v)
+	}
+
+	return nil
+}
+
+func resourceIndexMatchRuleUpdate(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	requestBody, err := indexMatchRuleRequestBody(d)
+	if err != nil {
+		return err
+	}
+
+	endpoint := c.BuildRizhiyiURL(nil, "..", "v3", "indexmatchrules", d.Id())
+	resp, err := c.Put(endpoint, requestBody)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return resourceIndexMatchRuleRead(d, m)
+}
+
+func resourceIndexMatchRuleDelete(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+
+	endpoint := c.BuildRizhiyiURL(nil, "..", "v3", "indexmatchrules", d.Id())
+	resp, err := c.Delete(endpoint)
+	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,495 @@
+package provider
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"terraform-provider-rizhiyi/yottaweb"
+)
+
+func resourceIndexSet() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceIndexSetCreate,
+		Read:   resourceIndexSetRead,
+		Update: resourceIndexSetUpdate,
+		Delete: resourceIndexSetDelete,
+
+		Importer: &schema.ResourceImporter{
+			State: resourceIndexSetImport,
+		},
+
+		CustomizeDiff: resourceIndexSetCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"index": &schema.Schema{
+				Type:        schema.TypeSet,
+				Required:    true,
+				MinItems:    1,
+				Description: "Indexes managed by the set, keyed by name. Creating an index whose name already exists fails; bring existing indexes under the set with terraform import, using their names comma separated as the ID.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": &schema.Schema{
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Index name.",
+						},
+						"pattern": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "kCompression",
+							ValidateFunc: validation.StringInSlice([]string{"kCompression", "kNumeric", "kNormal"}, false),
+							Description:  "Index mode, as rizhiyi_index.pattern. Changing it replaces the index. (default value kCompression)",
+						},
+						"description": &schema.Schema{
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Index description.",
+						},
+						"expired_time": &schema.Schema{
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateIndexDuration,
+							Description:  "Retention time, for example: 10d.",
+						},
+						"rotation_period": &schema.Schema{
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateIndexDuration,
+							Description:  "Partitioning time, for example: 1d.",
+						},
+						"number_of_replicas": &schema.Schema{
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     1,
+							Description: "Number of replicas. (default value 1)",
+						},
+						"domain_id": &schema.Schema{
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     1,
+							Description: "Domain ID. (default value 1)",
+						},
+						"disabled": &schema.Schema{
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Whether the index is disabled. (default value false)",
+						},
+					},
+				},
+			},
+			"index_ids": &schema.Schema{
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Computed:    true,
+				Description: "IDs of the managed indexes, keyed by name.",
+			},
+			"parallelism": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      4,
+				ValidateFunc: validation.IntBetween(1, 32),
+				Description:  "Maximum number of index API calls in flight at once. Each created, updated or deleted index is one call; only refresh lists all indexes in a single call. (default value 4)",
+			},
+			"deletion_protection": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Whether Terraform refuses to delete indexes, whether removed from the set, replaced or destroyed with it. Set it to false and force_destroy to true, and apply, before indexes and their data can be removed. (default value true)",
+			},
+			"force_destroy": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Confirms that deleting an index also drops all of its data. Required together with deletion_protection = false. (default value false)",
+			},
+		},
+	}
+}
+
+// indexSetEntries maps the index blocks of a set by name.
+func indexSetEntries(v interface{}) map[string]map[string]interface{} {
+	entries := map[string]map[string]interface{}{}
+	set, ok := v.(*schema.Set)
+	if !ok {
+		return entries
+	}
+	for _, raw := range set.List() {
+		entry := raw.(map[string]interface{})
+		entries[entry["name"].(string)] = entry
+	}
+	return entries
+}
+
+// indexSetPlan is what a reconcile does to each index, by name.
+type indexSetPlan struct {
+	Create []string
+	Update []string
+	Delete []string
+}
+
+// planIndexSet compares the old and new index blocks; a pattern change deletes
+// and recreates the index.
+func planIndexSet(oldEntries, newEntries map[string]map[string]interface{}) indexSetPlan {
+	var plan indexSetPlan
+	for name, entry := range newEntries {
+		old, ok := oldEntries[name]
+		switch {
+		case !ok:
+			plan.Create = append(plan.Create, name)
+		case old["pattern"] != entry["pattern"]:
+			plan.Delete = append(plan.Delete, name)
+			plan.Create = append(plan.Create, name)
+		case !indexSetEntryEqual(old, entry):
+			plan.Update = append(plan.Update, name)
+		}
+	}
+	for name := range oldEntries {
+		if _, ok := newEntries[name]; !ok {
+			plan.Delete = append(plan.Delete, name)
+		}
+	}
+	sort.Strings(plan.Create)
+	sort.Strings(plan.Update)
+	sort.Strings(plan.Delete)
+	return plan
+}
+
+func indexSetEntryEqual(a, b map[string]interface{}) bool {
+	for k, v := range a {
+		if k == "expired_time" || k == "rotation_period" {
+			if !indexDurationsEqual(v.(string), b[k].(string)) {
+				return false
+			}
+			continue
+		}
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func checkIndexSetDeletion(names []string, protected, forceDestroy bool) error {
+	if len(names) == 0 {
+		return nil
+	}
+	if protected {
+		return fmt.Errorf("deleting indexes %s drops their data but deletion_protection is enabled; set deletion_protection = false and force_destroy = true and apply first", strings.Join(names, ", "))
+	}
+	if !forceDestroy {
+		return fmt.Errorf("deleting indexes %s drops all of their data; set force_destroy = true and apply first", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// resourceIndexSetCustomizeDiff refuses plans that delete or replace indexes
+// while deletion is not confirmed.
+func resourceIndexSetCustomizeDiff(d *schema.ResourceDiff, m interface{}) error {
+	if d.Id() == "" || !d.HasChange("index") {
+		return nil
+	}
+	o, n := d.GetChange("index")
+	plan := planIndexSet(indexSetEntries(o), indexSetEntries(n))
+	// 与 rizhiyi_index 一致，删除确认需先单独 apply，因此使用 state 中的值
+	protected, _ := d.GetChange("deletion_protection")
+	forceDestroy, _ := d.GetChange("force_destroy")
+	return checkIndexSetDeletion(plan.Delete, protected.(bool), forceDestroy.(bool))
+}
+
+func indexSetRequestBody(entry, existing map[string]interface{}) map[string]interface{} {
+	requestBody := map[string]interface{}{}
+	// 更新时保留平台上未由本资源管理的字段
+	for k, v := range existing {
+		requestBody[k] = v
+	}
+	requestBody["name"] = entry["name"].(string)
+	requestBody["pattern"] = entry["pattern"].(string)
+	requestBody["description"] = entry["description"].(string)
+	requestBody["expired"] = entry["expired_time"].(string)
+	requestBody["rotation_period"] = entry["rotation_period"].(string)
+	requestBody["number_of_replicas"] = entry["number_of_replicas"].(int)
+	requestBody["domain_id"] = entry["domain_id"].(int)
+	requestBody["disabled"] = entry["disabled"].(bool)
+	return requestBody
+}
+
+// listIndexesByName lists every index in one call, keyed by name.
+func listIndexesByName(c *yottaweb.Client) (map[string]map[string]interface{}, error) {
+	indexes, err := listResources(c, "..", "v3", "indexes")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes: %s", err)
+	}
+	byName := map[string]map[string]interface{}{}
+	for _, index := range indexes {
+		byName[stringValue(index["name"])] = index
+	}
+	return byName, nil
+}
+
+// runIndexSetCalls runs fn for every name with at most parallelism calls in
+// flight, and returns the errors of all failed calls.
+func runIndexSetCalls(names []string, parallelism int, fn func(name string) error) error {
+	sem := make(chan struct{}, parallelism)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var errs []string
+	for _, name := range names {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(name string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := fn(name); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Sprintf("%s: %s", name, err))
+				mu.Unlock()
+			}
+		}(name)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("%d index calls failed:\n%s", len(errs), strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// listIndexesForPlan lists the indexes and refuses to create indexes whose name
+// is already taken, rather than silently taking them over.
+func listIndexesForPlan(c *yottaweb.Client, plan indexSetPlan) (map[string]map[string]interface{}, error) {
+	existing, err := listIndexesByName(c)
+	if err != nil {
+		return nil, err
+	}
+	var taken []string
+	for _, name := range plan.Create {
+		if _, ok := existing[name]; ok && !stringInList(name, plan.Delete) {
+			taken = append(taken, name)
+		}
+	}
+	if len(taken) > 0 {
+		return nil, fmt.Errorf("indexes %s already exist; import them with terraform import (ID: the set's index names, comma separated) instead of creating them", strings.Join(taken, ", "))
+	}
+	return existing, nil
+}
+
+// reconcileIndexSet applies plan: deletes first, so replaced indexes free their
+// name, then creates and updates.
+func reconcileIndexSet(c *yottaweb.Client, d *schema.ResourceData, plan indexSetPlan, entries, existing map[string]map[string]interface{}) error {
+	parallelism := d.Get("parallelism").(int)
+	if err := runIndexSetCalls(plan.Delete, parallelism, func(name string) error {
+		index, ok := existing[name]
+		if !ok {
+			return nil
+		}
+		params := url.Values{}
+		params.Add("engine", "beaver")
+		params.Add("index_name", name)
+		resp, err := c.Delete(c.BuildRizhiyiURL(params, "..", "v3", "indexes", idString(index["id"])))
+		if err != nil {
+			if strings.Contains(err.Error(), "404") {
+				return nil
+			}
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if err := runIndexSetCalls(plan.Create, parallelism, func(name string) error {
+		resp, err := c.Post(c.BuildRizhiyiURL(nil, "..", "v3", "indexes"), indexSetRequestBody(entries[name], nil))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}); err != nil {
+		return err
+	}
+	return runIndexSetCalls(plan.Update, parallelism, func(name string) error {
+		index, ok := existing[name]
+		if !ok {
+			return fmt.Errorf("index no longer exists")
+		}
+		id := idString(index["id"])
+		resp, err := c.Put(c.BuildRizhiyiURL(nil, "..", "v3", "indexes", id), indexSetRequestBody(entries[name], index))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	})
+}
+
+func resourceIndexSetCreate(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	entries := indexSetEntries(d.Get("index"))
+	plan := planIndexSet(nil, entries)
+
+	existing, err := listIndexesForPlan(c, plan)
+	if err != nil {
+		return err
+	}
+	// 部分失败时，已创建的索引仍记录到 state（资源会被标记为 tainted）
+	d.SetId(randomID("index-set-"))
+	if err := reconcileIndexSet(c, d, plan, entries, existing); err != nil {
+		return indexSetPartialError(d, m, err)
+	}
+	return resourceIndexSetRead(d, m)
+}
+
+// indexSetPartialError records the indexes a failed reconcile left behind, so
+// none of them are orphaned, and returns err.
+func indexSetPartialError(d *schema.ResourceData, m interface{}, err error) error {
+	// 删除失败的索引也要保留，state 取新旧 index 的并集再按平台刷新
+	o, n := d.GetChange("index")
+	merged := n.(*schema.Set).List()
+	current := indexSetEntries(n)
+	for name, entry := range indexSetEntries(o) {
+		if _, ok := current[name]; !ok {
+			merged = append(merged, entry)
+		}
+	}
+	d.Set("index", merged)
+	if readErr := resourceIndexSetRead(d, m); readErr != nil {
+		return fmt.Errorf("%s; refreshing the set also failed: %s", err, readErr)
+	}
+	if len(d.Get("index_ids").(map[string]interface{})) == 0 {
+		d.SetId("")
+	}
+	return err
+}
+
+func resourceIndexSetRead(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	existing, err := listIndexesByName(c)
+	if err != nil {
+		return err
+	}
+
+	current := indexSetEntries(d.Get("index"))
+	indexes := make([]interface{}, 0, len(current))
+	ids := map[string]interface{}{}
+	for name, entry := range current {
+		index, ok := existing[name]
+		if !ok {
+			// 已在平台删除，从 state 移除以便下次重建
+			continue
+		}
+		ids[name] = idString(index["id"])
+		indexes = append(indexes, flattenIndexSetEntry(entry, index))
+	}
+	d.Set("index", indexes)
+	d.Set("index_ids", ids)
+	return nil
+}
+
+// flattenIndexSetEntry maps a listed index onto an index block, keeping the
+// configured duration spelling when equivalent.
+func flattenIndexSetEntry(entry, index map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{
+		"name":               entry["name"],
+		"pattern":            stringValue(index["pattern"]),
+		"description":        stringValue(index["description"]),
+		"expired_time":       stringValue(index["expired"]),
+		"rotation_period":    stringValue(index["rotation_period"]),
+		"number_of_replicas": entry["number_of_replicas"],
+		"domain_id":          entry["domain_id"],
+		"disabled":           indexBool(index["disabled"]),
+	}
+	for _, k := range []string{"expired_time", "rotation_period"} {
+		if indexDurationsEqual(entry[k].(string), result[k].(string)) {
+			result[k] = entry[k]
+		}
+	}
+	if v, ok := toInt(index["number_of_replicas"]); ok {
+		result["number_of_replicas"] = v
+	}
+	if v, ok := toInt(index["domain_id"]); ok {
+		result["domain_id"] = v
+	}
+	return result
+}
+
+func resourceIndexSetUpdate(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	if !d.HasChange("index") {
+		return resourceIndexSetRead(d, m)
+	}
+	o, n := d.GetChange("index")
+	entries := indexSetEntries(n)
+	plan := planIndexSet(indexSetEntries(o), entries)
+	protected, _ := d.GetChange("deletion_protection")
+	forceDestroy, _ := d.GetChange("force_destroy")
+	if err := checkIndexSetDeletion(plan.Delete, protected.(bool), forceDestroy.(bool)); err != nil {
+		return err
+	}
+	existing, err := listIndexesForPlan(c, plan)
+	if err != nil {
+		return err
+	}
+	if err := reconcileIndexSet(c, d, plan, entries, existing); err != nil {
+		return indexSetPartialError(d, m, err)
+	}
+	return resourceIndexSetRead(d, m)
+}
+
+func resourceIndexSetDelete(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	entries := indexSetEntries(d.Get("index"))
+	plan := planIndexSet(entries, nil)
+	if err := checkIndexSetDeletion(plan.Delete, d.Get("deletion_protection").(bool), d.Get("force_destroy").(bool)); err != nil {
+		return err
+	}
+	existing, err := listIndexesByName(c)
+	if err != nil {
+		return err
+	}
+	if err := reconcileIndexSet(c, d, plan, nil, existing); err != nil {
+		return err
+	}
+	d.SetId("")
+	return nil
+}
+
+// resourceIndexSetImport takes over existing indexes, given their names comma
+// separated.
+func resourceIndexSetImport(d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	c := m.(*yottaweb.Client)
+	existing, err := listIndexesByName(c)
+	if err != nil {
+		return nil, err
+	}
+	indexes := make([]interface{}, 0)
+	for _, name := range strings.Split(d.Id(), ",") {
+		name = strings.TrimSpace(name)
+		index, ok := existing[name]
+		if !ok {
+			return nil, fmt.Errorf("index %q not found", name)
+		}
+		entry := map[string]interface{}{
+			"name":               name,
+			"expired_time":       "",
+			"rotation_period":    "",
+			"number_of_replicas": 1,
+			"domain_id":          1,
+		}
+		indexes = append(indexes, flattenIndexSetEntry(entry, index))
+	}
+	d.SetId(randomID("index-set-"))
+	d.Set("index", indexes)
+	d.Set("parallelism", 4)
+	d.Set("deletion_protection", true)
+	d.Set("force_destroy", false)
+	return []*schema.ResourceData{d}, nil
+}
@@ -1,10 +1,12 @@
 package provider
 
 import (
+	"github.com/hashicorp/terraform-plugin-sdk/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 	"terraform-provider-rizhiyi/yottaweb"
 	"encoding/json"
 	"io"
+	"log"
 	"strconv"
 )
 
@@ -16,6 +18,8 @@ func resourceParserRule() *schema.Resource {
 		Update: resourceParserRuleUpdate,
 		Delete: resourceParserRuleDelete,
 
+		CustomizeDiff: customdiff.Sequence(resourceParserRuleStepDiff, resourceParserRuleAssignmentDiff),
+
 		Schema: map[string]*schema.Schema{
 			"name": &schema.Schema{
 				Type:     schema.TypeString,
@@ -55,13 +59,31 @@ func resourceParserRule() *schema.Resource {
 				Type:     schema.TypeList,
 				Elem:     &schema.Schema{Type: schema.TypeString},
 				Optional: true,
-				Description: "ParserRule appname & tag",
+				ConflictsWith: []string{"assignment"},
+				Description: "ParserRule appname & tag, each entry as appname:tag or appname:tag:hostname. Prefer assignment.",
+			},
+			"assignment": parserRuleAssignmentSchema(),
+			"shared_assignments": &schema.Schema{
+				Type:     schema.TypeList,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Computed: true,
+				Description: "Appname/tag pairs of assignment that other parser rules also claim, as appname:tag (rule name).",
+			},
+			"fail_on_shared_assignment": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether plans fail when another parser rule claims an appname/tag pair of assignment. (default value false)",
 			},
 			"conf": &schema.Schema{
 				Type:     schema.TypeString,
-				Required: true,
-				Description: "Parsing rules included in the ParserRule. for examples: (Parsing rules for JSON) \"[{\"json\":{\"rule\":[{\"add_fields\":[],\"source\":\"raw_message\",\"another_name\":\"\",\"paths\":[],\"extract_limit\":\"\"}]}}]\"",
+				Optional: true,
+				Computed: true,
+				ExactlyOneOf: []string{"conf", "step"},
+				DiffSuppressFunc: suppressEquivalentJSON,
+				Description: "Parsing rules included in the ParserRule. for examples: (Parsing rules for JSON) \"[{\"json\":{\"rule\":[{\"add_fields\":[],\"source\":\"raw_message\",\"another_name\":\"\",\"paths\":[],\"extract_limit\":\"\"}]}}]\". Prefer step; when step is set, conf shows the JSON the steps serialize to at plan time.",
 			},
+			"step": parserRuleStepSchema(),
 			"event_list": &schema.Schema{
 				Type:     schema.TypeList,
 				Elem:     &schema.Schema{Type: schema.TypeString},
@@ -80,8 +102,11 @@ func resourceParserRuleCreate(d *schema.ResourceData, m interface{}) error {
 	category_id := d.Get("category_id").(int)
 	app_id := d.Get("app_id").(int)
 	rt_names := d.Get("rt_names").(string)
-	assign_data := d.Get("assign_data").([]interface{})
-	conf := d.Get("conf").(string)
+	assign_data := parserRuleAssignData(d)
+	conf, err := parserRuleConf(d)
+	if err != nil {
+		return err
+	}
 	event_list := d.Get("event_list").([]interface{})
 
 	requestBody := map[string]interface{}{
@@ -179,8 +204,22 @@ func resourceParserRuleRead(d *schema.ResourceData, m interface{}) error {
 	d.Set("category_id", data["category_id"])
 	d.Set("app_id", data["app_id"])
 	d.Set("rt_names", data["rt_names"])
-	d.Set("assign_data", data["assign_data"])
-	d.Set("conf", data["conf"])
+	if assignments := expandParserAssignments(d.Get("assignment")); len(assignments) > 0 {
+		// assignment 与 assign_data 二选一，由 assignment 承载平台上的值
+		d.Set("assign_data", nil)
+		d.Set("assignment", flattenParserAssignments(data["assign_data"]))
+		// 其他规则列表读取失败时保留原值，不阻塞 refresh
+		if shared, err := sharedParserAssignments(c, id, assignments); err != nil {
+			log.Printf("[WARN] parser rule %s: %s", id, err)
+		} else {
+			d.Set("shared_assignments", shared)
+		}
+	} else {
+		d.Set("assign_data", data["assign_data"])
+	}
+	if err := setParserRuleConf(d, data["conf"]); err != nil {
+		return err
+	}
 	d.Set("event_list", data["event_list"])
 
 	return nil
@@ -194,8 +233,11 @@ func resourceParserRuleUpdate(d *schema.ResourceData, m interface{}) error {
 	category_id := d.Get("category_id").(int)
 	app_id := d.Get("app_id").(int)
 	rt_names := d.Get("rt_names").(string)
-	assign_data := d.Get("assign_data").([]interface{})
-	conf := d.Get("conf").(string)
+	assign_data := parserRuleAssignData(d)
+	conf, err := parserRuleConf(d)
+	if err != nil {
+		return err
+	}
 	event_list := d.Get("event_list").([]interface{})
 
 	requestBody := map[string]interface{}{
@@ -218,6 +260,7 @@ func resourceParserRuleUpdate(d *schema.ResourceData, m interface{}) error {
 	}
 
 	defer resp.Body.Close()
+	d.Set("conf", conf)
 
 	// keep numeric id stable
 	return nil
@@ -0,0 +1,249 @@
+package provider
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"terraform-provider-rizhiyi/yottaweb"
+)
+
+var reportRecipientPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+$`)
+
+func resourceReport() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceReportCreate,
+		Read:   resourceReportRead,
+		Update: resourceReportUpdate,
+		Delete: resourceReportDelete,
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the report.",
+			},
+			"description": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Description of the report.",
+			},
+			"enabled": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Whether the report is sent on schedule. (default value true)",
+			},
+			"crontab": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.All(validateCrontab, validateCrontabSchedule),
+				Description:  "Crontab statement of the schedule, using the same format as rizhiyi_alert.crontab, for example: 0 0 8 ? * MON.",
+			},
+			"format": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "pdf",
+				ValidateFunc: validation.StringInSlice([]string{"pdf", "excel", "html"}, false),
+				Description:  "File format of the report: pdf, excel or html. (default value pdf)",
+			},
+			"trend_ids": &schema.Schema{
+				Type:         schema.TypeList,
+				Elem:         &schema.Schema{Type: schema.TypeInt},
+				Optional:     true,
+				ExactlyOneOf: []string{"trend_ids", "dashboard_id"},
+				Description:  "IDs of the trends included in the report, in order, for example rizhiyi_trend.x.id.",
+			},
+			"dashboard_id": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"trend_ids", "dashboard_id"},
+				Description:  "ID of the dashboard the report is built from.",
+			},
+			"tab_id": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				RequiredWith: []string{"dashboard_id"},
+				Description:  "ID of the dashboard tab included. Defaults to every tab.",
+			},
+			"time_range": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "-1d,now",
+				Description: "Time range the report searches cover, for example: -1d,now. (default value -1d,now)",
+			},
+			"recipients": &schema.Schema{
+				Type:        schema.TypeSet,
+				Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validation.StringMatch(reportRecipientPattern, "must be an email address")},
+				Required:    true,
+				MinItems:    1,
+				Description: "Email addresses the report is sent to.",
+			},
+			"subject": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "{{name}} {{date}}",
+				Description: "Email subject template; {{name}} is replaced with the report name and {{date}} with the send date. (default value {{name}} {{date}})",
+			},
+			"content": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Email body sent with the report.",
+			},
+			"app_id": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Computed:    true,
+				Description: "ID of the app the report belongs to.",
+			},
+		},
+	}
+}
+
+func reportRequestBody(d *schema.ResourceData) map[string]interface{} {
+	trendIDs := make([]string, 0)
+	for _, v := range d.Get("trend_ids").([]interface{}) {
+		trendIDs = append(trendIDs, strconv.Itoa(v.(int)))
+	}
+	recipients := make([]string, 0)
+	for _, v := range d.Get("recipients").(*schema.Set).List() {
+		recipients = append(recipients, v.(string))
+	}
+	sort.Strings(recipients)
+
+	requestBody := map[string]interface{}{
+		"name":         d.Get("name").(string),
+		"description":  d.Get("description").(string),
+		"enabled":      d.Get("enabled").(bool),
+		"crontab":      d.Get("crontab").(string),
+		"report_type":  d.Get("format").(string),
+		"trend_ids":    strings.Join(trendIDs, ","),
+		"dashboard_id": d.Get("dashboard_id").(string),
+		"tab_id":       d.Get("tab_id").(string),
+		"time_range":   d.Get("time_range").(string),
+		"receivers":    strings.Join(recipients, ","),
+		"subject":      d.Get("subject").(string),
+		"content":      d.Get("content").(string),
+	}
+	if v := d.Get("app_id").(int); v > 0 {
+		requestBody["app_id"] = v
+	}
+	return requestBody
+}
+
+func resourceReportCreate(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	name := d.Get("name").(string)
+
+	endpoint := c.BuildRizhiyiURL(nil, "v3", "reports")
+	resp, err := c.Post(endpoint, reportRequestBody(d))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	d.SetId(idFromCreateResponse(bodyBytes))
+	if d.Id() == "" {
+		if rid, _ := c.GetResourceIdByName(name, "v3", "reports"); rid != "" {
+			d.SetId(rid)
+		}
+	}
+	if d.Id() == "" {
+		return fmt.Errorf("report created but id not resolvable: %s", name)
+	}
+	return resourceReportRead(d, m)
+}
+
+func resourceReportRead(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	id := d.Id()
+	if id == "" {
+		return nil
+	}
+
+	data, err := c.GetResourceById(id, "v3", "reports")
+	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("name", data["name"])
+	d.Set("description", stringValue(data["description"]))
+	if v, ok := data["enabled"].(bool); ok {
+		d.Set("enabled", v)
+	}
+	d.Set("crontab", data["crontab"])
+	if v := stringValue(data["report_type"]); v != "" {
+		d.Set("format", v)
+	}
+	trendIDs := make([]interface{}, 0)
+	for _, s := range splitIDs(data["trend_ids"]) {
+		if v, err := strconv.Atoi(s); err == nil {
+			trendIDs = append(trendIDs, v)
+		}
+	}
+	d.Set("trend_ids", trendIDs)
+	for _, k := range []string{"dashboard_id", "tab_id"} {
+		// 未设置时平台返回 0 或 null
+		if v := idString(data[k]); v != "0" {
+			d.Set(k, v)
+		} else {
+			d.Set(k, "")
+		}
+	}
+	if v := stringValue(data["time_range"]); v != "" {
+		d.Set("time_range", v)
+	}
+	recipients := make([]interface{}, 0)
+	for _, r := range splitIDs(data["receivers"]) {
+		recipients = append(recipients, r)
+	}
+	d.Set("recipients", schema.NewSet(schema.HashString, recipients))
+	d.Set("subject", stringValue(data["subject"]))
+	d.Set("content", stringValue(data["content"]))
+	if v, ok := toInt(data["app_id"]); ok {
+		d.Set("app_id", v)
+	}
+
+	return nil
+}
+
+func resourceReportUpdate(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+
+	endpoint := c.BuildRizhiyiURL(nil, "v3", "reports", d.Id())
+	resp, err := c.Put(endpoint, reportRequestBody(d))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return resourceReportRead(d, m)
+}
+
+func resourceReportDelete(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+
+	endpoint := c.BuildRizhiyiURL(nil, "v3", "reports", d.Id())
+	resp, err := c.Delete(endpoint)
+	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	d.SetId("")
+	return nil
+}
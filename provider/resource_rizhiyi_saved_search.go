@@ -0,0 +1,261 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"terraform-provider-rizhiyi/yottaweb"
+)
+
+func resourceSavedSearch() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceSavedSearchCreate,
+		Read:   resourceSavedSearchRead,
+		Update: resourceSavedSearchUpdate,
+		Delete: resourceSavedSearchDelete,
+
+		CustomizeDiff: customdiff.Sequence(
+			customdiff.ComputedIf("app_id", func(d *schema.ResourceDiff, m interface{}) bool {
+				return d.HasChange("app_name")
+			}),
+			customdiff.ComputedIf("resource_group_ids", func(d *schema.ResourceDiff, m interface{}) bool {
+				return d.HasChange("resource_groups")
+			}),
+			resourceSavedSearchRevisionDiff,
+		),
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the saved search.",
+			},
+			"description": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Description of the saved search.",
+			},
+			"query": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Search content of the saved search.",
+			},
+			"time_range": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "-1h,now",
+				Description: "Time range of the search, for example: -1h,now. (default value -1h,now)",
+			},
+			"app_id": &schema.Schema{
+				Type:          schema.TypeInt,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"app_name"},
+				Description:   "ID of the app the saved search belongs to.",
+			},
+			"app_name": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"app_id"},
+				Description:   "Name of the app the saved search belongs to, resolved to app_id at apply time.",
+			},
+			"rt_names": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"resource_groups"},
+				Description:   "Resource group names the saved search belongs to, comma separated.",
+			},
+			"resource_groups": &schema.Schema{
+				Type:          schema.TypeSet,
+				Elem:          &schema.Schema{Type: schema.TypeString},
+				Optional:      true,
+				ConflictsWith: []string{"rt_names"},
+				Description:   "Names of the resource groups the saved search belongs to, checked against the platform at apply time.",
+			},
+			"resource_group_ids": &schema.Schema{
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Computed:    true,
+				Description: "IDs the resource_groups names resolved to.",
+			},
+			"export": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "local",
+				ValidateFunc: validation.StringInSlice([]string{"local", "system"}, false),
+				Description:  "Resource scope: local (visible within the app) or system (globally visible). (default value local)",
+			},
+			"share_role_ids": &schema.Schema{
+				Type:        schema.TypeSet,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Optional:    true,
+				Description: "IDs of the roles the saved search is shared with.",
+			},
+			"share_account_ids": &schema.Schema{
+				Type:        schema.TypeSet,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Optional:    true,
+				Description: "IDs of the accounts the saved search is shared with.",
+			},
+			"revision": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Hash of query, planned together with it. Pass it to rizhiyi_alert.saved_search_revision so alerts pick up query changes in the same apply.",
+			},
+		},
+	}
+}
+
+func savedSearchRequestBody(d *schema.ResourceData) map[string]interface{} {
+	requestBody := map[string]interface{}{
+		"name":           d.Get("name").(string),
+		"description":    d.Get("description").(string),
+		"query":          d.Get("query").(string),
+		"time_range":     d.Get("time_range").(string),
+		"rt_names":       d.Get("rt_names").(string),
+		"export":         d.Get("export").(string),
+		"share_roles":    joinIntSet(d.Get("share_role_ids").(*schema.Set)),
+		"share_accounts": joinIntSet(d.Get("share_account_ids").(*schema.Set)),
+	}
+	if v := d.Get("app_id").(int); v > 0 {
+		requestBody["app_id"] = v
+	}
+	return requestBody
+}
+
+func resourceSavedSearchCreate(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	name := d.Get("name").(string)
+	if err := resolveOwnerReferences(c, d); err != nil {
+		return err
+	}
+
+	endpoint := c.BuildRizhiyiURL(nil, "v3", "savedsearches")
+	resp, err := c.Post(endpoint, savedSearchRequestBody(d))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	d.SetId(idFromCreateResponse(bodyBytes))
+	if d.Id() == "" {
+		if rid, _ := c.GetResourceIdByName(name, "v3", "savedsearches"); rid != "" {
+			d.SetId(rid)
+		}
+	}
+	if d.Id() == "" {
+		return fmt.Errorf("saved search created but id not resolvable: %s", name)
+	}
+	return resourceSavedSearchRead(d, m)
+}
+
+func resourceSavedSearchRead(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	id := d.Id()
+	if id == "" {
+		return nil
+	}
+
+	data, err := c.GetResourceById(id, "v3", "savedsearches")
+	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("name", data["name"])
+	d.Set("description", stringValue(data["description"]))
+	d.Set("query", data["query"])
+	d.Set("revision", savedSearchRevision(stringValue(data["query"])))
+	if v := stringValue(data["time_range"]); v != "" {
+		d.Set("time_range", v)
+	}
+	if v, ok := toInt(data["app_id"]); ok {
+		d.Set("app_id", v)
+	}
+	if v, ok := data["rt_names"].(string); ok {
+		d.Set("rt_names", v)
+	}
+	if v := stringValue(data["export"]); v != "" {
+		d.Set("export", v)
+	}
+	d.Set("share_role_ids", intSetFromIDs(data["share_roles"]))
+	d.Set("share_account_ids", intSetFromIDs(data["share_accounts"]))
+
+	return nil
+}
+
+func resourceSavedSearchUpdate(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	if err := resolveOwnerReferences(c, d); err != nil {
+		return err
+	}
+
+	endpoint := c.BuildRizhiyiURL(nil, "v3", "savedsearches", d.Id())
+	resp, err := c.Put(endpoint, savedSearchRequestBody(d))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return resourceSavedSearchRead(d, m)
+}
+
+func resourceSavedSearchDelete(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+
+	endpoint := c.BuildRizhiyiURL(nil, "v3", "savedsearches", d.Id())
+	resp, err := c.Delete(endpoint)
+	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	d.SetId("")
+	return nil
+}
+
+// savedSearchRevision identifies a version of a saved search query.
+func savedSearchRevision(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:8])
+}
+
+// resourceSavedSearchRevisionDiff plans the revision of a changed query, so the
+// alerts referencing it are planned for update too.
+func resourceSavedSearchRevisionDiff(d *schema.ResourceDiff, m interface{}) error {
+	if !d.HasChange("query") {
+		return nil
+	}
+	if !d.NewValueKnown("query") {
+		return d.SetNewComputed("revision")
+	}
+	return d.SetNew("revision", savedSearchRevision(d.Get("query").(string)))
+}
+
+// savedSearchQuery returns the query of a saved search.
+func savedSearchQuery(c *yottaweb.Client, id string) (string, error) {
+	data, err := c.GetResourceById(id, "v3", "savedsearches")
+	if err != nil {
+		return "", fmt.Errorf("failed to read saved search %s: %s", id, err)
+	}
+	query := stringValue(data["query"])
+	if query == "" {
+		return "", fmt.Errorf("saved search %s has no query", id)
+	}
+	return query, nil
+}
@@ -0,0 +1,265 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"terraform-provider-rizhiyi/yottaweb"
+)
+
+func resourceTrend() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceTrendCreate,
+		Read:   resourceTrendRead,
+		Update: resourceTrendUpdate,
+		Delete: resourceTrendDelete,
+
+		CustomizeDiff: customdiff.Sequence(
+			customdiff.ComputedIf("app_id", func(d *schema.ResourceDiff, m interface{}) bool {
+				return d.HasChange("app_name")
+			}),
+			customdiff.ComputedIf("resource_group_ids", func(d *schema.ResourceDiff, m interface{}) bool {
+				return d.HasChange("resource_groups")
+			}),
+		),
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the trend (saved chart).",
+			},
+			"description": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Description of the trend.",
+			},
+			"query": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Search query the chart is drawn from.",
+			},
+			"time_range": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "-1h,now",
+				Description: "Time range of the search, for example: -1h,now. (default value -1h,now)",
+			},
+			"chart_type": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Chart type, for example: line, column, pie, sequence.",
+			},
+			"chart_options": &schema.Schema{
+				Type:             schema.TypeString,
+				Optional:         true,
+				Default:          "{}",
+				ValidateFunc:     validation.StringIsJSON,
+				DiffSuppressFunc: suppressEquivalentJSON,
+				Description:      "Other chart settings merged into the trend search data (JSON string), for example xField or byFields.",
+			},
+			"app_id": &schema.Schema{
+				Type:          schema.TypeInt,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"app_name"},
+				Description:   "ID of the app the trend belongs to.",
+			},
+			"app_name": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"app_id"},
+				Description:   "Name of the app the trend belongs to, resolved to app_id at apply time.",
+			},
+			"rt_names": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"resource_groups"},
+				Description:   "Resource group names the trend belongs to, comma separated.",
+			},
+			"resource_groups": &schema.Schema{
+				Type:          schema.TypeSet,
+				Elem:          &schema.Schema{Type: schema.TypeString},
+				Optional:      true,
+				ConflictsWith: []string{"rt_names"},
+				Description:   "Names of the resource groups the trend belongs to, checked against the platform at apply time.",
+			},
+			"resource_group_ids": &schema.Schema{
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Computed:    true,
+				Description: "IDs the resource_groups names resolved to.",
+			},
+		},
+	}
+}
+
+func trendRequestBody(d *schema.ResourceData) (map[string]interface{}, error) {
+	searchData := make(map[string]interface{})
+	for k, v := range dashboardSearchDataDefaults {
+		searchData[k] = v
+	}
+	if opts := d.Get("chart_options").(string); opts != "" {
+		var extra map[string]interface{}
+		if err := json.Unmarshal([]byte(opts), &extra); err != nil {
+			return nil, fmt.Errorf("chart_options: %s", err)
+		}
+		for k, v := range extra {
+			searchData[k] = v
+		}
+	}
+	searchData["query"] = d.Get("query").(string)
+	searchData["time_range"] = d.Get("time_range").(string)
+	searchData["chartType"] = d.Get("chart_type").(string)
+	searchData["trendName"] = d.Get("name").(string)
+	data, err := json.Marshal(searchData)
+	if err != nil {
+		return nil, err
+	}
+
+	requestBody := map[string]interface{}{
+		"name":        d.Get("name").(string),
+		"description": d.Get("description").(string),
+		"data":        string(data),
+		"rt_names":    d.Get("rt_names").(string),
+	}
+	if v := d.Get("app_id").(int); v > 0 {
+		requestBody["app_id"] = v
+	}
+	return requestBody, nil
+}
+
+func resourceTrendCreate(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	if err := resolveOwnerReferences(c, d); err != nil {
+		return err
+	}
+	requestBody, err := trendRequestBody(d)
+	if err != nil {
+		return err
+	}
+
+	id, err := createTrend(c, requestBody)
+	if err != nil {
+		return fmt.Errorf("failed to create trend %s: %s", d.Get("name").(string), err)
+	}
+	d.SetId(id)
+	return resourceTrendRead(d, m)
+}
+
+func resourceTrendRead(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	id := d.Id()
+	if id == "" {
+		return nil
+	}
+
+	data, err := c.GetResourceById(id, "v3", "trends")
+	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("name", data["name"])
+	d.Set("description", stringValue(data["description"]))
+	if v, ok := toInt(data["app_id"]); ok {
+		d.Set("app_id", v)
+	}
+	if v, ok := data["rt_names"].(string); ok {
+		d.Set("rt_names", v)
+	}
+	if _, ok := d.GetOk("resource_groups"); ok {
+		groups := splitIDs(data["rt_names"])
+		d.Set("resource_groups", groups)
+		ids, err := resolveResourceGroupIDs(c, groups)
+		if err != nil {
+			return err
+		}
+		d.Set("resource_group_ids", ids)
+	}
+
+	// data 可能是 JSON 字符串，也可能已经是对象
+	var searchData map[string]interface{}
+	switch v := data["data"].(type) {
+	case string:
+		if v != "" {
+			if err := json.Unmarshal([]byte(v), &searchData); err != nil {
+				return fmt.Errorf("failed to decode data of trend %s: %s", id, err)
+			}
+		}
+	case map[string]interface{}:
+		searchData = v
+	}
+	extra := make(map[string]interface{})
+	for k, v := range searchData {
+		switch k {
+		case "query":
+			d.Set("query", stringValue(v))
+		case "time_range":
+			d.Set("time_range", stringValue(v))
+		case "chartType":
+			d.Set("chart_type", stringValue(v))
+		case "trendName":
+		default:
+			if def, ok := dashboardSearchDataDefaults[k]; ok && reflect.DeepEqual(def, v) {
+				continue
+			}
+			extra[k] = v
+		}
+	}
+	options := "{}"
+	if len(extra) > 0 {
+		b, _ := json.Marshal(extra)
+		options = string(b)
+	}
+	d.Set("chart_options", options)
+
+	return nil
+}
+
+func resourceTrendUpdate(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+	if err := resolveOwnerReferences(c, d); err != nil {
+		return err
+	}
+	requestBody, err := trendRequestBody(d)
+	if err != nil {
+		return err
+	}
+
+	endpoint := c.BuildRizhiyiURL(nil, "v3", "trends", d.Id())
+	resp, err := c.Put(endpoint, requestBody)
+	if err != nil {
+		return fmt.Errorf("failed to update trend %s: %s", d.Id(), err)
+	}
+	defer resp.Body.Close()
+
+	return resourceTrendRead(d, m)
+}
+
+func resourceTrendDelete(d *schema.ResourceData, m interface{}) error {
+	c := m.(*yottaweb.Client)
+
+	endpoint := c.BuildRizhiyiURL(nil, "v3", "trends", d.Id())
+	resp, err := c.Delete(endpoint)
+	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	d.SetId("")
+	return nil
+}
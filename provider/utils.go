@@ -0,0 +1,216 @@
+package provider
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"terraform-provider-rizhiyi/yottaweb"
+)
+
+// idString normalises an ID value from a JSON response (float64, int or string).
+func idString(v interface{}) string {
+	switch iv := v.(type) {
+	case float64:
+		return strconv.Itoa(int(iv))
+	case int:
+		return strconv.Itoa(iv)
+	case string:
+		return iv
+	case nil:
+		return ""
+	default:
+		return fmt.Sprintf("%v", iv)
+	}
+}
+
+// idFromCreateResponse extracts the new resource ID from a create response,
+// which is either {"object": 12} or {"object": {"id": 12}}.
+func idFromCreateResponse(bodyBytes []byte) string {
+	var respData map[string]interface{}
+	if err := json.Unmarshal(bodyBytes, &respData); err != nil {
+		return ""
+	}
+	switch v := respData["object"].(type) {
+	case map[string]interface{}:
+		return idString(v["id"])
+	case nil:
+		return ""
+	default:
+		return idString(v)
+	}
+}
+
+// listFromResponse returns the item list of a list response,
+// compatible with v2 (objects, resources) and v3 (list).
+func listFromResponse(data map[string]interface{}) []interface{} {
+	if v, ok := data["list"].([]interface{}); ok {
+		return v
+	}
+	if v, ok := data["objects"].([]interface{}); ok {
+		return v
+	}
+	if v, ok := data["resources"].([]interface{}); ok {
+		return v
+	}
+	return nil
+}
+
+// listResources fetches every object of a list endpoint.
+func listResources(c *yottaweb.Client, resourceNameParts ...string) ([]map[string]interface{}, error) {
+	endpoint := c.BuildRizhiyiURL(nil, resourceNameParts...)
+	resp, err := c.Get(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var data map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+	items := make([]map[string]interface{}, 0)
+	for _, item := range listFromResponse(data) {
+		if obj, ok := item.(map[string]interface{}); ok {
+			items = append(items, obj)
+		}
+	}
+	return items, nil
+}
+
+// splitIDs turns a comma separated ID string or a JSON array into a string slice.
+func splitIDs(v interface{}) []string {
+	ids := make([]string, 0)
+	switch vv := v.(type) {
+	case string:
+		for _, part := range strings.Split(vv, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				ids = append(ids, part)
+			}
+		}
+	case []interface{}:
+		for _, it := range vv {
+			if s := idString(it); s != "" {
+				ids = append(ids, s)
+			}
+		}
+	}
+	return ids
+}
+
+// resolveIDByName looks up the numeric ID of a named object, failing when it does not exist.
+func resolveIDByName(c *yottaweb.Client, name, kind string, resourceNameParts ...string) (int, error) {
+	rid, err := c.GetResourceIdByName(name, resourceNameParts...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up %s %q: %s", kind, name, err)
+	}
+	id, err := strconv.Atoi(rid)
+	if rid == "" || err != nil {
+		return 0, fmt.Errorf("%s %q not found", kind, name)
+	}
+	return id, nil
+}
+
+// resolveResourceGroupIDs resolves resource group names to IDs with a single list call.
+func resolveResourceGroupIDs(c *yottaweb.Client, names []string) ([]int, error) {
+	ids := make([]int, 0, len(names))
+	if len(names) == 0 {
+		return ids, nil
+	}
+	groups, err := listResources(c, "v3", "resourcegroups")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resource groups: %s", err)
+	}
+	byName := make(map[string]int, len(groups))
+	for _, g := range groups {
+		name, _ := g["name"].(string)
+		if id, ok := toInt(g["id"]); ok {
+			byName[name] = id
+		}
+	}
+	for _, name := range names {
+		id, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("resource group %q not found", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// validateCrontab checks a crontab statement in the format rizhiyi_alert uses:
+// second minute hour day-of-month month day-of-week [year], for example 0 0 8 ? * MON.
+func validateCrontab(i interface{}, k string) (warnings []string, errors []error) {
+	v, ok := i.(string)
+	if !ok {
+		errors = append(errors, fmt.Errorf("expected type of %s to be string", k))
+		return
+	}
+	if n := len(strings.Fields(v)); n != 6 && n != 7 {
+		errors = append(errors, fmt.Errorf("expected %s to have 6 or 7 fields (second minute hour day-of-month month day-of-week [year]), got %q", k, v))
+	}
+	return
+}
+
+// joinIntSet renders a set of IDs as the sorted comma separated string the API uses.
+func joinIntSet(set *schema.Set) string {
+	ids := make([]int, 0, set.Len())
+	for _, v := range set.List() {
+		ids = append(ids, v.(int))
+	}
+	sort.Ints(ids)
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.Itoa(id))
+	}
+	return strings.Join(parts, ",")
+}
+
+// intSetFromIDs builds a set of IDs from a comma separated string or JSON array.
+func intSetFromIDs(v interface{}) *schema.Set {
+	ids := make([]interface{}, 0)
+	for _, s := range splitIDs(v) {
+		if id, err := strconv.Atoi(s); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return schema.NewSet(schema.HashInt, ids)
+}
+
+// randomID returns an ID for resources that only exist in Terraform state.
+func randomID(prefix string) string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return prefix + hex.EncodeToString(b)
+}
+
+// resolveOwnerReferences resolves the app_name and resource_groups of a trend,
+// saved search or alert before a write.
+func resolveOwnerReferences(c *yottaweb.Client, d *schema.ResourceData) error {
+	if v, ok := d.GetOk("app_name"); ok {
+		id, err := resolveIDByName(c, v.(string), "app", "v3", "apps")
+		if err != nil {
+			return err
+		}
+		d.Set("app_id", id)
+	}
+	if v, ok := d.GetOk("resource_groups"); ok {
+		groups := make([]string, 0)
+		for _, g := range v.(*schema.Set).List() {
+			groups = append(groups, g.(string))
+		}
+		sort.Strings(groups)
+		ids, err := resolveResourceGroupIDs(c, groups)
+		if err != nil {
+			return err
+		}
+		d.Set("rt_names", strings.Join(groups, ","))
+		d.Set("resource_group_ids", ids)
+	}
+	return nil
+}
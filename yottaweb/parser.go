@@ -0,0 +1,62 @@
+package yottaweb
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ParsePreviewResult is what a parser rule extracted from one sample line
+type ParsePreviewResult struct {
+	Fields map[string]interface{}
+	Error  string
+}
+
+// PreviewParse runs a parser rule conf over sample lines without saving the rule
+func (c *Client) PreviewParse(conf, logtype string, lines []string) ([]ParsePreviewResult, error) {
+	body := map[string]interface{}{
+		"conf":   conf,
+		"events": lines,
+	}
+	if logtype != "" {
+		body["logtype"] = logtype
+	}
+	endpoint := c.BuildRizhiyiURL(nil, "v3", "parserrules", "preview")
+	resp, err := c.Post(endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var data map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("invalid parse preview response: %s", err)
+	}
+	if res, ok := data["result"].(bool); ok && !res {
+		if errObj, ok := data["error"].(map[string]interface{}); ok {
+			return nil, fmt.Errorf("parse preview error: code=%v, message=%v", errObj["code"], errObj["message"])
+		}
+		return nil, fmt.Errorf("parse preview returned result: false")
+	}
+
+	// 兼容 object 与 list 两种返回结构
+	items, ok := data["object"].([]interface{})
+	if !ok {
+		items, _ = data["list"].([]interface{})
+	}
+	if len(items) != len(lines) {
+		return nil, fmt.Errorf("parse preview returned %d results for %d lines", len(items), len(lines))
+	}
+	results := make([]ParsePreviewResult, 0, len(items))
+	for _, item := range items {
+		obj, _ := item.(map[string]interface{})
+		result := ParsePreviewResult{Fields: map[string]interface{}{}}
+		if fields, ok := obj["fields"].(map[string]interface{}); ok {
+			result.Fields = fields
+		}
+		if msg, ok := obj["error"].(string); ok {
+			result.Error = msg
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,120 @@
+package yottaweb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+const (
+	RenderFormatPNG = "png"
+	RenderFormatPDF = "pdf"
+)
+
+// RenderRequest describes a dashboard tab to render
+type RenderRequest struct {
+	DashboardID string
+	TabID       string
+	Format      string
+	TimeRange   string
+	Width       int
+	Height      int
+	Timeout     int
+}
+
+// RenderResult is a rendered dashboard file
+type RenderResult struct {
+	ContentType string
+	Body        []byte
+}
+
+// renderURL returns the render endpoint: RenderEndpoint when set (a local stub in
+// tests), otherwise the report rendering endpoint of the platform.
+func (c *Client) renderURL() (url.URL, error) {
+	if c.RenderEndpoint == "" {
+		return c.BuildRizhiyiURL(nil, "v3", "reports", "render"), nil
+	}
+	u, err := url.Parse(c.RenderEndpoint)
+	if err != nil {
+		return url.URL{}, fmt.Errorf("invalid render endpoint %q: %s", c.RenderEndpoint, err)
+	}
+	return *u, nil
+}
+
+// RenderDashboard renders a dashboard tab as a PNG or PDF file
+func (c *Client) RenderDashboard(req RenderRequest) (*RenderResult, error) {
+	endpoint, err := c.renderURL()
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]interface{}{
+		"type":         "dashboard",
+		"dashboard_id": req.DashboardID,
+		"format":       req.Format,
+	}
+	if req.TabID != "" {
+		body["tab_id"] = req.TabID
+	}
+	if req.TimeRange != "" {
+		body["time_range"] = req.TimeRange
+	}
+	if req.Width > 0 {
+		body["width"] = req.Width
+	}
+	if req.Height > 0 {
+		body["height"] = req.Height
+	}
+	if req.Timeout > 0 {
+		body["timeout"] = req.Timeout
+	}
+
+	resp, err := c.Post(endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	contentType := resp.Header.Get("Content-Type")
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(contentType, "application/json") {
+		return &RenderResult{ContentType: contentType, Body: data}, nil
+	}
+
+	// 渲染服务也可能返回文件下载地址而非文件本身
+	var respData map[string]interface{}
+	if err := json.Unmarshal(data, &respData); err != nil {
+		return nil, fmt.Errorf("invalid render response: %s", err)
+	}
+	if res, ok := respData["result"].(bool); ok && !res {
+		return nil, fmt.Errorf("render failed: %s", string(data))
+	}
+	obj, _ := respData["object"].(map[string]interface{})
+	link, _ := obj["url"].(string)
+	if link == "" {
+		return nil, fmt.Errorf("render response has neither a file nor a download url: %s", string(data))
+	}
+	return c.downloadRender(endpoint, link)
+}
+
+func (c *Client) downloadRender(base url.URL, link string) (*RenderResult, error) {
+	ref, err := url.Parse(link)
+	if err != nil {
+		return nil, fmt.Errorf("invalid render download url %q: %s", link, err)
+	}
+	resp, err := c.Get(*base.ResolveReference(ref))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &RenderResult{ContentType: resp.Header.Get("Content-Type"), Body: data}, nil
+}
@@ -0,0 +1,152 @@
+package yottaweb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SearchCategoryEvents = "events"
+	SearchCategorySheets = "sheets"
+
+	searchPollInterval = 2 * time.Second
+)
+
+// SearchResult is the outcome of a finished search job
+type SearchResult struct {
+	Status string
+	Total  int
+	Fields []string
+	Rows   []map[string]interface{}
+}
+
+// SubmitSearch submits a search job and returns its sid
+func (c *Client) SubmitSearch(query, timeRange, category string) (string, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("time_range", timeRange)
+	params.Set("category", category)
+	endpoint := c.BuildRizhiyiURL(params, "v3", "search", "submit")
+	resp, err := c.Get(endpoint)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := decodeSearchResponse(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	sid := ""
+	switch v := data["sid"].(type) {
+	case string:
+		sid = v
+	case float64:
+		sid = strconv.Itoa(int(v))
+	}
+	if sid == "" {
+		return "", fmt.Errorf("search submit returned no sid: %v", data)
+	}
+	return sid, nil
+}
+
+// FetchSearch fetches the status and, once finished, the results of a search job
+func (c *Client) FetchSearch(sid, category string) (*SearchResult, error) {
+	params := url.Values{}
+	params.Set("sid", sid)
+	params.Set("category", category)
+	endpoint := c.BuildRizhiyiURL(params, "v3", "search", "fetch")
+	resp, err := c.Get(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := decodeSearchResponse(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	result := &SearchResult{}
+	result.Status, _ = data["job_status"].(string)
+
+	results, _ := data["results"].(map[string]interface{})
+	if results == nil {
+		return result, nil
+	}
+	if v, ok := results["total_hits"].(float64); ok {
+		result.Total = int(v)
+	} else if v, ok := results["total"].(float64); ok {
+		result.Total = int(v)
+	}
+
+	// events 返回 hits，sheets 返回 sheets.rows
+	var rows []interface{}
+	if v, ok := results["hits"].([]interface{}); ok {
+		rows = v
+	} else if sheets, ok := results["sheets"].(map[string]interface{}); ok {
+		rows, _ = sheets["rows"].([]interface{})
+		if fields, ok := sheets["_field_infos_"].([]interface{}); ok {
+			for _, f := range fields {
+				if fm, ok := f.(map[string]interface{}); ok {
+					if name, ok := fm["name"].(string); ok {
+						result.Fields = append(result.Fields, name)
+					}
+				}
+			}
+		}
+	}
+	for _, r := range rows {
+		if row, ok := r.(map[string]interface{}); ok {
+			result.Rows = append(result.Rows, row)
+		}
+	}
+	if result.Total == 0 {
+		result.Total = len(result.Rows)
+	}
+	return result, nil
+}
+
+// RunSearch submits a search job and polls it until it finishes or the timeout expires
+func (c *Client) RunSearch(query, timeRange, category string, timeout time.Duration) (*SearchResult, error) {
+	sid, err := c.SubmitSearch(query, timeRange, category)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		result, err := c.FetchSearch(sid, category)
+		if err != nil {
+			return nil, err
+		}
+		switch strings.ToUpper(result.Status) {
+		case "COMPLETED", "FINISHED", "DONE":
+			return result, nil
+		case "FAILED", "ERROR", "CANCELED", "KILLED":
+			return nil, fmt.Errorf("search job %s ended with status %s", sid, result.Status)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("search job %s did not finish within %s", sid, timeout)
+		}
+		time.Sleep(searchPollInterval)
+	}
+}
+
+// decodeSearchResponse decodes a search API response and surfaces result: false errors
+func decodeSearchResponse(body io.Reader) (map[string]interface{}, error) {
+	var data map[string]interface{}
+	if err := json.NewDecoder(body).Decode(&data); err != nil {
+		return nil, err
+	}
+	if res, ok := data["result"].(bool); ok && !res {
+		if errObj, ok := data["error"].(map[string]interface{}); ok {
+			return nil, fmt.Errorf("search API error: code=%v, message=%v", errObj["code"], errObj["message"])
+		}
+		return nil, fmt.Errorf("search API returned result: false")
+	}
+	return data, nil
+}
@@ -30,6 +30,8 @@ type Client struct {
 	Host          string
 	Authorization string
 	HTTPClient    *http.Client
+	// RenderEndpoint overrides the dashboard render endpoint, e.g. with a local stub
+	RenderEndpoint string
 }
 
 // NewClient creates a new yottaweb client
@@ -50,7 +52,9 @@ func (c *Client) Request(httpMethod, requestUrl string, body io.Reader) (*http.R
 	if err != nil {
 		return nil, err
 	}
-	request.Header.Set("Authorization", "Basic "+c.Authorization)
+	if c.isPlatformHost(request.URL) {
+		request.Header.Set("Authorization", "Basic "+c.Authorization)
+	}
 	request.Header.Set("Content-Type", "application/json")
 	request.Header.Set("Accept", "application/json")
 	request.Header.Set("X-Requested-With", "XMLHttpRequest")
@@ -141,7 +145,9 @@ func (c *Client) ensureCSRFCookie(requestURL url.URL) {
 	if err != nil {
 		return
 	}
-	req.Header.Set("Authorization", "Basic "+c.Authorization)
+	if c.isPlatformHost(req.URL) {
+		req.Header.Set("Authorization", "Basic "+c.Authorization)
+	}
 	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
 
 	resp, err := c.Do(req)
@@ -154,6 +160,23 @@ func (c *Client) ensureCSRFCookie(requestURL url.URL) {
 	_ = c.HTTPClient.Jar.Cookies(&u)
 }
 
+// platformHost returns the configured host without scheme and trailing slash
+func (c *Client) platformHost() string {
+	host := c.Host
+	if strings.HasPrefix(host, "http://") {
+		host = strings.TrimPrefix(host, "http://")
+	} else if strings.HasPrefix(host, "https://") {
+		host = strings.TrimPrefix(host, "https://")
+	}
+	return strings.TrimRight(host, "/")
+}
+
+// isPlatformHost reports whether u points at the configured platform, the only
+// host the credentials are sent to
+func (c *Client) isPlatformHost(u *url.URL) bool {
+	return strings.EqualFold(u.Host, c.platformHost())
+}
+
 // BuildRizhiyiURL Http request path
 func (c *Client) BuildRizhiyiURL(parametersValues url.Values, urlPathParts ...string) url.URL {
 	buildPath := "/api"
@@ -184,13 +207,7 @@ func (c *Client) BuildRizhiyiURL(parametersValues url.Values, urlPathParts ...st
 		parametersValues = url.Values{}
 	}
 	
-	host := c.Host
-	if strings.HasPrefix(host, "http://") {
-		host = strings.TrimPrefix(host, "http://")
-	} else if strings.HasPrefix(host, "https://") {
-		host = strings.TrimPrefix(host, "https://")
-	}
-	host = strings.TrimRight(host, "/")
+	host := c.platformHost()
 
 	// To avoid http response truncation
 	parametersValues.Set("count", "-1")
@@ -309,6 +326,11 @@ func (c *Client) Put(putURL url.URL, body map[string]interface{}) (*http.Respons
 	return c.DoRequest(MethodPut, putURL, body)
 }
 
+// Patch func
+func (c *Client) Patch(patchURL url.URL, body map[string]interface{}) (*http.Response, error) {
+	return c.DoRequest(MethodPatch, patchURL, body)
+}
+
 // Delete func
 func (c *Client) Delete(deleteURL url.URL) (*http.Response, error) {
 	return c.DoRequest(MethodDelete, deleteURL, nil)